@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AdminHandler returns an http.Handler which serves the runtime level control, like [zap.AtomicLevel.ServeHTTP],
+// but covering the caller level and the sink levels too.
+//
+// GET returns the current level, PUT alters the level,
+// the body of PUT can be JSON {"level":"debug"} or form encoding level=debug.
+//
+//	GET     /                 all levels
+//	GET|PUT /level            logger level
+//	GET|PUT /caller           caller level, see CallerCore
+//	GET     /sinks            levels of all sinks
+//	GET|PUT /sinks/{name}     level of the named sink
+//	GET     /names            level overrides of the logger names
+//	GET|PUT|DELETE /names/{pattern}  level override of the logger name pattern, see Log.SetNameLevel
+//
+// Use http.StripPrefix to mount it under a sub path.
+func (l *Log) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		sinks := make(map[string]Level)
+		for name, lv := range l.SinkLevels() {
+			sinks[name] = lv.Level()
+		}
+		writeAdminJSON(w, http.StatusOK, struct {
+			Level       Level            `json:"level"`
+			CallerLevel Level            `json:"callerLevel"`
+			Sinks       map[string]Level `json:"sinks"`
+			Names       map[string]Level `json:"names"`
+		}{l.GetLevel(), l.callerCore.Level(), sinks, l.NameLevels()})
+	})
+	mux.HandleFunc("GET /level", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminLevel(w, l.GetLevel())
+	})
+	mux.HandleFunc("PUT /level", func(w http.ResponseWriter, r *http.Request) {
+		lvl, ok := decodeAdminLevel(w, r)
+		if ok {
+			writeAdminLevel(w, l.SetLevel(lvl).GetLevel())
+		}
+	})
+	mux.HandleFunc("GET /caller", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminLevel(w, l.callerCore.Level())
+	})
+	mux.HandleFunc("PUT /caller", func(w http.ResponseWriter, r *http.Request) {
+		lvl, ok := decodeAdminLevel(w, r)
+		if ok {
+			writeAdminLevel(w, l.SetCallerLevel(lvl).callerCore.Level())
+		}
+	})
+	mux.HandleFunc("GET /sinks", func(w http.ResponseWriter, r *http.Request) {
+		sinks := make(map[string]Level)
+		for name, lv := range l.SinkLevels() {
+			sinks[name] = lv.Level()
+		}
+		writeAdminJSON(w, http.StatusOK, struct {
+			Sinks map[string]Level `json:"sinks"`
+		}{sinks})
+	})
+	mux.HandleFunc("GET /sinks/{name}", func(w http.ResponseWriter, r *http.Request) {
+		lv, ok := l.SinkLevel(r.PathValue("name"))
+		if !ok {
+			writeAdminError(w, http.StatusNotFound, fmt.Errorf("sink %q not found", r.PathValue("name")))
+			return
+		}
+		writeAdminLevel(w, lv.Level())
+	})
+	mux.HandleFunc("PUT /sinks/{name}", func(w http.ResponseWriter, r *http.Request) {
+		lv, ok := l.SinkLevel(r.PathValue("name"))
+		if !ok {
+			writeAdminError(w, http.StatusNotFound, fmt.Errorf("sink %q not found", r.PathValue("name")))
+			return
+		}
+		lvl, ok := decodeAdminLevel(w, r)
+		if ok {
+			lv.SetLevel(lvl)
+			writeAdminLevel(w, lv.Level())
+		}
+	})
+	mux.HandleFunc("GET /names", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, struct {
+			Names map[string]Level `json:"names"`
+		}{l.NameLevels()})
+	})
+	mux.HandleFunc("GET /names/{pattern}", func(w http.ResponseWriter, r *http.Request) {
+		lvl, ok := l.NameLevels()[r.PathValue("pattern")]
+		if !ok {
+			writeAdminError(w, http.StatusNotFound, fmt.Errorf("name %q not found", r.PathValue("pattern")))
+			return
+		}
+		writeAdminLevel(w, lvl)
+	})
+	mux.HandleFunc("PUT /names/{pattern}", func(w http.ResponseWriter, r *http.Request) {
+		lvl, ok := decodeAdminLevel(w, r)
+		if !ok {
+			return
+		}
+		if err := l.SetNameLevel(r.PathValue("pattern"), lvl); err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeAdminLevel(w, lvl)
+	})
+	mux.HandleFunc("DELETE /names/{pattern}", func(w http.ResponseWriter, r *http.Request) {
+		l.DeleteNameLevel(r.PathValue("pattern"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+type adminLevel struct {
+	Level *Level `json:"level"`
+}
+
+// decodeAdminLevel decodes the level from JSON or form body,
+// it writes the error response and returns false if failed.
+func decodeAdminLevel(w http.ResponseWriter, r *http.Request) (Level, bool) {
+	var lvl Level
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		text := r.FormValue("level")
+		if text == "" {
+			err = errors.New("must specify logging level")
+		} else {
+			err = lvl.UnmarshalText([]byte(text))
+		}
+	} else {
+		var req adminLevel
+		if err = json.NewDecoder(r.Body).Decode(&req); err == nil {
+			if req.Level == nil {
+				err = errors.New("must specify logging level")
+			} else {
+				lvl = *req.Level
+			}
+		}
+	}
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return zapcore.InvalidLevel, false
+	}
+	return lvl, true
+}
+
+func writeAdminLevel(w http.ResponseWriter, lvl Level) {
+	writeAdminJSON(w, http.StatusOK, adminLevel{Level: &lvl})
+}
+
+func writeAdminError(w http.ResponseWriter, code int, err error) {
+	writeAdminJSON(w, code, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+func writeAdminJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
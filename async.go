@@ -0,0 +1,265 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// async overflow policy defined
+const (
+	OverflowBlock      = "block"       // block until the buffer has space
+	OverflowDropNewest = "drop-newest" // drop the incoming entry
+	OverflowDropOldest = "drop-oldest" // drop the oldest entry in the buffer
+	OverflowDropBelow  = "drop-below"  // drop the incoming entry below AsyncConfig.DropLevel, others block
+)
+
+// async default defined
+const (
+	defaultAsyncSize          = 1024
+	defaultAsyncFlushInterval = time.Second
+)
+
+type asyncEntry struct {
+	level Level
+	buf   *buffer.Buffer
+}
+
+// asyncWriter is a bounded ring buffer of encoded entries in front of the sink writer,
+// which flushed by a background goroutine.
+type asyncWriter struct {
+	ws        zapcore.WriteSyncer
+	policy    string
+	dropLevel Level
+	dropped   atomic.Uint64
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    []asyncEntry
+	head    int
+	count   int
+	closed  bool
+
+	flushMu sync.Mutex // serializes the flushes, keep the order of entries
+	batch   []asyncEntry
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newAsyncWriter(ws zapcore.WriteSyncer, ac *AsyncConfig) *asyncWriter {
+	size := ac.Size
+	if size <= 0 {
+		size = defaultAsyncSize
+	}
+	interval := defaultAsyncFlushInterval
+	if d, err := time.ParseDuration(ac.FlushInterval); err == nil && d > 0 {
+		interval = d
+	}
+	dropLevel := ErrorLevel
+	if lv, err := zapcore.ParseLevel(ac.DropLevel); err == nil && ac.DropLevel != "" {
+		dropLevel = lv
+	}
+	w := &asyncWriter{
+		ws:        ws,
+		policy:    ac.Overflow,
+		dropLevel: dropLevel,
+		ring:      make([]asyncEntry, size),
+		batch:     make([]asyncEntry, 0, size),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	go w.run(interval)
+	return w
+}
+
+// enqueue puts the encoded entry into the buffer, applies the overflow policy if full.
+func (w *asyncWriter) enqueue(lvl Level, buf *buffer.Buffer) {
+	w.mu.Lock()
+	for !w.closed && w.count == len(w.ring) {
+		switch {
+		case w.policy == OverflowDropNewest,
+			w.policy == OverflowDropBelow && lvl < w.dropLevel:
+			w.mu.Unlock()
+			w.dropped.Add(1)
+			buf.Free()
+			return
+		case w.policy == OverflowDropOldest:
+			w.ring[w.head].buf.Free()
+			w.ring[w.head] = asyncEntry{}
+			w.head = (w.head + 1) % len(w.ring)
+			w.count--
+			w.dropped.Add(1)
+		default: // block
+			w.notify()
+			w.notFull.Wait()
+		}
+	}
+	if w.closed {
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		buf.Free()
+		return
+	}
+	w.ring[(w.head+w.count)%len(w.ring)] = asyncEntry{level: lvl, buf: buf}
+	w.count++
+	halfFull := w.count >= len(w.ring)/2
+	w.mu.Unlock()
+	if halfFull {
+		w.notify()
+	}
+}
+
+// notify wakes up the flusher.
+func (w *asyncWriter) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *asyncWriter) run(interval time.Duration) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.wake:
+		case <-ticker.C:
+		}
+		_ = w.flush()
+	}
+}
+
+// flush writes all the buffered entries to the sink writer.
+func (w *asyncWriter) flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	for ; w.count > 0; w.count-- {
+		w.batch = append(w.batch, w.ring[w.head])
+		w.ring[w.head] = asyncEntry{}
+		w.head = (w.head + 1) % len(w.ring)
+	}
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	var errs []error
+	for i, e := range w.batch {
+		if _, err := w.ws.Write(e.buf.Bytes()); err != nil {
+			errs = append(errs, err)
+		}
+		e.buf.Free()
+		w.batch[i] = asyncEntry{}
+	}
+	w.batch = w.batch[:0]
+	return errors.Join(errs...)
+}
+
+// Sync drains the buffer fully, and syncs the sink writer.
+func (w *asyncWriter) Sync() error {
+	return errors.Join(w.flush(), w.ws.Sync())
+}
+
+// Close stops the flusher, drains the buffer and syncs the sink writer,
+// the further entries are dropped.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+	close(w.stop)
+	<-w.done
+	err := w.flush()
+	_ = w.ws.Sync()
+	return err
+}
+
+// Dropped returns the number of dropped entries.
+func (w *asyncWriter) Dropped() uint64 { return w.dropped.Load() }
+
+// inheritDropped carries the dropped counters of the old async writers over to the writers of the same sink,
+// so the counters are kept across reload.
+func (s *coreState) inheritDropped(old *coreState) {
+	for name, w := range old.asyncs {
+		if nw, ok := s.asyncs[name]; ok {
+			nw.dropped.Add(w.Dropped())
+		}
+	}
+}
+
+// asyncCore is a zapcore.Core which encodes the entries synchronously,
+// and writes them asynchronously through the asyncWriter.
+type asyncCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	out *asyncWriter
+}
+
+func newAsyncCore(enc zapcore.Encoder, out *asyncWriter, enab zapcore.LevelEnabler) *asyncCore {
+	return &asyncCore{LevelEnabler: enab, enc: enc, out: out}
+}
+
+// With implements zapcore.Core.
+func (c *asyncCore) With(fields []Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	return &asyncCore{LevelEnabler: c.LevelEnabler, enc: enc, out: c.out}
+}
+
+// Check implements zapcore.Core.
+func (c *asyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *asyncCore) Write(ent zapcore.Entry, fields []Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	c.out.enqueue(ent.Level, buf)
+	if ent.Level > ErrorLevel {
+		// Since we may be crashing the program, sync the output.
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync implements zapcore.Core.
+func (c *asyncCore) Sync() error { return c.out.Sync() }
+
+// AsyncDropped returns the number of dropped entries of the async sinks, keyed by sink name.
+// It returns nil if async not enabled.
+func (l *Log) AsyncDropped() map[string]uint64 {
+	if l.pipe == nil {
+		return nil
+	}
+	state := l.pipe.core.root.Load()
+	if len(state.asyncs) == 0 {
+		return nil
+	}
+	dropped := make(map[string]uint64, len(state.asyncs))
+	for name, w := range state.asyncs {
+		dropped[name] = w.Dropped()
+	}
+	return dropped
+}
@@ -10,6 +10,9 @@ import (
 
 const loggerPackage = "github.com/thinkgos/logger"
 
+// defaultCallerLevel the default caller level of CallerCore.
+const defaultCallerLevel = ErrorLevel
+
 type CallerCore struct {
 	level        AtomicLevel
 	Skip         int
@@ -19,7 +22,7 @@ type CallerCore struct {
 
 func NewCallerCore() *CallerCore {
 	return &CallerCore{
-		level:        NewAtomicLevelAt(ErrorLevel),
+		level:        NewAtomicLevelAt(defaultCallerLevel),
 		Skip:         0,
 		SkipPackages: nil,
 		Caller:       DefaultCallerFile,
@@ -0,0 +1,44 @@
+package logger
+
+import "context"
+
+type logContextKey struct{}
+
+type fieldsContextKey struct{}
+
+// NewContext returns a copy of ctx with the logger, see FromContext.
+func NewContext(ctx context.Context, l *Log) context.Context {
+	return context.WithValue(ctx, logContextKey{}, l)
+}
+
+// FromContext returns the logger stored by NewContext, or the default logger if not present.
+func FromContext(ctx context.Context) *Log {
+	if ctx != nil {
+		if l, ok := ctx.Value(logContextKey{}).(*Log); ok && l != nil {
+			return l
+		}
+	}
+	return defaultLogger
+}
+
+// WithFields returns a copy of ctx with the fields appended to the fields already in ctx,
+// which are appended to the *Event by OnLevelContext and the OnXxxContext methods.
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	old := FieldsFromContext(ctx)
+	fs := make([]Field, 0, len(old)+len(fields))
+	fs = append(fs, old...)
+	fs = append(fs, fields...)
+	return context.WithValue(ctx, fieldsContextKey{}, fs)
+}
+
+// FieldsFromContext returns the fields stored by WithFields.
+func FieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fs, _ := ctx.Value(fieldsContextKey{}).([]Field)
+	return fs
+}
@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultDedupWindow the default window of collapsing the consecutive identical entries.
+const defaultDedupWindow = time.Second
+
+// dedup field key defined
+const (
+	repeatedKey = "repeated" // how many times the entry repeated, not including the first one
+	firstKey    = "first"    // timestamp of the first repeated entry
+	lastKey     = "last"     // timestamp of the last repeated entry
+)
+
+// dupEntry is a run of the consecutive identical entries.
+type dupEntry struct {
+	key      uint64       // fingerprint of the entry
+	core     zapcore.Core // the core which the entry written to, with the context fields
+	ent      zapcore.Entry
+	fields   []Field
+	start    time.Time // time of the entry which started the run, it has been written
+	first    time.Time // time of the first suppressed entry
+	last     time.Time // time of the last suppressed entry
+	repeated int
+}
+
+// dedupState is shared by the dedupCore and its children.
+type dedupState struct {
+	window time.Duration
+	seed   maphash.Seed
+
+	mu    sync.Mutex
+	run   *dupEntry   // the current run, nil if none
+	timer *time.Timer // flushes the summary of the current run when the window expired
+	// held while the summary is flushed by the timer or Sync, so Sync waits for the timer, lock before mu.
+	writeMu sync.Mutex
+}
+
+// dedupCore is a zapcore.Core which collapses the consecutive identical (logger name, level, message, fields) entries within a window,
+// the first entry is written immediately, the following identical ones are suppressed,
+// and a summary entry with the repeated count and the first/last timestamps is written
+// when a different entry arrives, the window expired, a DPanic or higher entry arrives or synced.
+type dedupCore struct {
+	zapcore.Core
+	enc   zapcore.Encoder // fingerprint encoder, holds the context fields
+	state *dedupState
+}
+
+func newDedupCore(core zapcore.Core, dc *DedupConfig) *dedupCore {
+	window := defaultDedupWindow
+	if d, err := time.ParseDuration(dc.Window); err == nil && d > 0 {
+		window = d
+	}
+	return &dedupCore{
+		Core: core,
+		enc:  zapcore.NewJSONEncoder(zapcore.EncoderConfig{}), // only fields are encoded
+		state: &dedupState{
+			window: window,
+			seed:   maphash.MakeSeed(),
+		},
+	}
+}
+
+// With implements zapcore.Core.
+func (c *dedupCore) With(fields []Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	return &dedupCore{Core: c.Core.With(fields), enc: enc, state: c.state}
+}
+
+// Check implements zapcore.Core.
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= DPanicLevel { // never suppress the entry which may crash the program
+		if c.Core.Enabled(ent.Level) {
+			c.state.flush() // the summary of the earlier entries goes first, the program may exit after the entry
+		}
+		return c.Core.Check(ent, ce)
+	}
+	if c.Core.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *dedupCore) Write(ent zapcore.Entry, fields []Field) error {
+	key, err := c.fingerprint(ent, fields)
+	if err != nil {
+		return err
+	}
+
+	s := c.state
+	s.mu.Lock()
+	if d := s.run; d != nil && d.key == key && ent.Time.Sub(d.start) < s.window {
+		if d.repeated == 0 {
+			d.first = ent.Time
+			s.timer = time.AfterFunc(s.window-ent.Time.Sub(d.start), func() { s.expire(d) })
+		}
+		d.last = ent.Time
+		d.repeated++
+		s.mu.Unlock()
+		return nil
+	}
+	flushed := s.take()
+	s.run = &dupEntry{
+		key:    key,
+		core:   c.Core,
+		ent:    ent,
+		fields: append([]Field(nil), fields...),
+		start:  ent.Time,
+	}
+	s.mu.Unlock()
+
+	writeSummary(flushed)
+	writeEntry(c.Core, ent, fields)
+	return nil
+}
+
+// Sync implements zapcore.Core.
+func (c *dedupCore) Sync() error {
+	c.state.flush()
+	return c.Core.Sync()
+}
+
+// flush writes the summary of the current run.
+func (s *dedupState) flush() {
+	s.writeMu.Lock() // wait for the summary which is being written by the timer
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	flushed := s.take()
+	s.mu.Unlock()
+
+	writeSummary(flushed)
+}
+
+// take removes the current run and stops its timer. the caller must hold the lock.
+func (s *dedupState) take() *dupEntry {
+	d := s.run
+	s.run = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return d
+}
+
+// expire writes the summary of the run when the window expired, if it is still the current run.
+func (s *dedupState) expire(d *dupEntry) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	if s.run != d {
+		s.mu.Unlock()
+		return
+	}
+	s.run = nil
+	s.timer = nil
+	s.mu.Unlock()
+	writeSummary(d)
+}
+
+// fingerprint returns the hash of the logger name, level, message and fields.
+func (c *dedupCore) fingerprint(ent zapcore.Entry, fields []Field) (uint64, error) {
+	buf, err := c.enc.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return 0, err
+	}
+	defer buf.Free()
+
+	var h maphash.Hash
+	h.SetSeed(c.state.seed)
+	_ = h.WriteByte(byte(ent.Level))
+	_, _ = h.WriteString(ent.LoggerName)
+	_ = h.WriteByte(0)
+	_, _ = h.WriteString(ent.Message)
+	_ = h.WriteByte(0)
+	_, _ = h.Write(buf.Bytes())
+	return h.Sum64(), nil
+}
+
+// writeSummary writes the summary entry of the run if it repeated.
+func writeSummary(d *dupEntry) {
+	if d == nil || d.repeated == 0 {
+		return
+	}
+	ent := d.ent
+	ent.Time = d.last
+	fields := append(d.fields,
+		zap.Int(repeatedKey, d.repeated),
+		zap.Time(firstKey, d.first),
+		zap.Time(lastKey, d.last),
+	)
+	writeEntry(d.core, ent, fields)
+}
+
+// writeEntry writes the entry through the core, which checks the sink levels.
+func writeEntry(core zapcore.Core, ent zapcore.Entry, fields []Field) {
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+}
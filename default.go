@@ -64,6 +64,24 @@ func Enabled(lvl Level) bool { return defaultLogger.Enabled(lvl) }
 // same as Enabled
 func V(lvl Level) bool { return defaultLogger.V(lvl) }
 
+// SinkLevel returns the level of the named sink, see SinkConsole, SinkFile, SinkCustom.
+func SinkLevel(name string) (AtomicLevel, bool) { return defaultLogger.SinkLevel(name) }
+
+// SinkLevels returns the levels of all sinks, keyed by sink name.
+func SinkLevels() map[string]AtomicLevel { return defaultLogger.SinkLevels() }
+
+// AsyncDropped returns the number of dropped entries of the async sinks, keyed by sink name.
+func AsyncDropped() map[string]uint64 { return defaultLogger.AsyncDropped() }
+
+// SetNameLevel set the level override for the loggers matched the pattern, see Log.SetNameLevel.
+func SetNameLevel(pattern string, lv Level) error { return defaultLogger.SetNameLevel(pattern, lv) }
+
+// DeleteNameLevel delete the level override of the pattern.
+func DeleteNameLevel(pattern string) { defaultLogger.DeleteNameLevel(pattern) }
+
+// NameLevels returns all the level overrides, keyed by pattern.
+func NameLevels() map[string]Level { return defaultLogger.NameLevels() }
+
 // Sugar wraps the Logger to provide a more ergonomic, but slightly slower,
 // API. Sugaring a Logger is quite inexpensive, so it's reasonable for a
 // single application to use both Loggers and SugaredLoggers, converting
@@ -130,6 +148,22 @@ func Named(name string) *Log { return defaultLogger.Named(name) }
 // Sync flushes any buffered log entries.
 func Sync() error { return defaultLogger.Sync() }
 
+// Close drains and closes all the sinks owned by the default logger, see Log.Close.
+func Close(ctx context.Context) error { return defaultLogger.Close(ctx) }
+
+// Recover recovers the panic, and logs it with the logger carried by the context, see Log.Recover.
+// It must be called directly by defer:
+//
+//	defer logger.Recover(ctx)
+func Recover(ctx context.Context, opts ...RecoverOption) {
+	if v := recover(); v != nil {
+		FromContext(ctx).handlePanic(ctx, v, opts, 2) // skip handlePanic and Recover
+	}
+}
+
+// Go runs the function in a goroutine, the panic is recovered and logged, see Log.Go.
+func Go(fn func(), opts ...RecoverOption) { defaultLogger.Go(fn, opts...) }
+
 // OnLevel starts a new message with customize level.
 //
 // You must call Msg on the returned event in order to send the event.
@@ -138,10 +172,11 @@ func OnLevel(level Level) *Event {
 }
 
 // OnLevelContext starts a new message with customize level, and adds the Go Context to the *Event context.
+// It uses the logger carried by the Go Context if present, see NewContext, and appends the fields carried by the Go Context, see WithFields.
 //
 // You must call Msg on the returned event in order to send the event.
 func OnLevelContext(ctx context.Context, level Level) *Event {
-	return defaultLogger.OnLevel(level).WithContext(ctx)
+	return FromContext(ctx).OnLevelContext(ctx, level)
 }
 
 // OnDebug starts a new message with [DebugLevel] level.
@@ -155,7 +190,7 @@ func OnDebug() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func OnDebugContext(ctx context.Context) *Event {
-	return defaultLogger.OnLevel(DebugLevel).WithContext(ctx)
+	return OnLevelContext(ctx, DebugLevel)
 }
 
 // OnInfo starts a new message with [InfoLevel] level.
@@ -169,7 +204,7 @@ func OnInfo() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func OnInfoContext(ctx context.Context) *Event {
-	return defaultLogger.OnLevel(InfoLevel).WithContext(ctx)
+	return OnLevelContext(ctx, InfoLevel)
 }
 
 // OnWarn starts a new message with [WarnLevel] level.
@@ -183,7 +218,7 @@ func OnWarn() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func OnWarnContext(ctx context.Context) *Event {
-	return defaultLogger.OnLevel(WarnLevel).WithContext(ctx)
+	return OnLevelContext(ctx, WarnLevel)
 }
 
 // OnError starts a new message with [ErrorLevel] level.
@@ -197,7 +232,7 @@ func OnError() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func OnErrorContext(ctx context.Context) *Event {
-	return defaultLogger.OnLevel(ErrorLevel).WithContext(ctx)
+	return OnLevelContext(ctx, ErrorLevel)
 }
 
 // OnDPanic starts a new message with [DPanicLevel] level.
@@ -211,7 +246,7 @@ func OnDPanic() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func OnDPanicContext(ctx context.Context) *Event {
-	return defaultLogger.OnLevel(DPanicLevel).WithContext(ctx)
+	return OnLevelContext(ctx, DPanicLevel)
 }
 
 // OnPanic starts a new message with [PanicLevel] level.
@@ -225,7 +260,7 @@ func OnPanic() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func OnPanicContext(ctx context.Context) *Event {
-	return defaultLogger.OnLevel(PanicLevel).WithContext(ctx)
+	return OnLevelContext(ctx, PanicLevel)
 }
 
 // OnFatal starts a new message with [FatalLevel] level.
@@ -239,7 +274,7 @@ func OnFatal() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func OnFatalContext(ctx context.Context) *Event {
-	return defaultLogger.OnLevel(FatalLevel).WithContext(ctx)
+	return OnLevelContext(ctx, FatalLevel)
 }
 
 func Debug(args ...any) {
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// low space policy defined
+const (
+	LowSpaceDrop = "drop" // drop the entries below error level
+	LowSpaceStop = "stop" // stop writing
+)
+
+// diskCheckInterval the interval to check the free space of the volume.
+const diskCheckInterval = 10 * time.Second
+
+// diskGuard checks the free space of the volume periodically.
+type diskGuard struct {
+	dir       string
+	minFree   uint64 // bytes
+	stop      bool   // stop writing or drop the entries below error level
+	low       atomic.Bool
+	warned    atomic.Bool
+	checkedAt atomic.Int64 // unix nano
+	others    zapcore.Core // the other sinks of the logger, which the warning is written to
+}
+
+func newDiskGuard(dir string, minFreeMB int, policy string) *diskGuard {
+	return &diskGuard{
+		dir:     dir,
+		minFree: uint64(minFreeMB) * 1024 * 1024,
+		stop:    policy == LowSpaceStop,
+	}
+}
+
+// isLow reports whether the free space is under the threshold, it checks at most once per diskCheckInterval.
+func (g *diskGuard) isLow() bool {
+	now := time.Now().UnixNano()
+	last := g.checkedAt.Load()
+	if now-last >= int64(diskCheckInterval) && g.checkedAt.CompareAndSwap(last, now) {
+		free, ok := diskFree(existingDir(g.dir))
+		low := ok && free < g.minFree
+		g.low.Store(low)
+		if !low {
+			g.warned.Store(false)
+		}
+	}
+	return g.low.Load()
+}
+
+// existingDir returns the nearest existing ancestor of the dir, as the dir may be created on the first write.
+func existingDir(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// diskGuardCore drops the entries of the sink when the free space of the volume is under the threshold,
+// and emits a one-time warning through the other sinks of the logger, or os.Stderr if there is no other sink.
+type diskGuardCore struct {
+	zapcore.Core
+	guard *diskGuard
+}
+
+// With implements zapcore.Core.
+func (c *diskGuardCore) With(fields []Field) zapcore.Core {
+	return &diskGuardCore{Core: c.Core.With(fields), guard: c.guard}
+}
+
+// Check implements zapcore.Core.
+func (c *diskGuardCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if c.guard.isLow() {
+		if c.guard.warned.CompareAndSwap(false, true) {
+			c.guard.warn(ent)
+		}
+		if c.guard.stop || ent.Level < ErrorLevel {
+			return ce
+		}
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (g *diskGuard) warn(ent zapcore.Entry) {
+	action := "drop the entries below error level"
+	if g.stop {
+		action = "stop writing"
+	}
+	warning := zapcore.Entry{
+		Level:      WarnLevel,
+		Time:       ent.Time,
+		LoggerName: ent.LoggerName,
+		Message:    "logger: free space of " + g.dir + " is under " + strconv.FormatUint(g.minFree/1024/1024, 10) + "MB, " + action,
+	}
+	if g.others == nil {
+		fmt.Fprintf(os.Stderr, "%v %s\n", warning.Time, warning.Message)
+		return
+	}
+	if ce := g.others.Check(warning, nil); ce != nil {
+		ce.Write()
+	}
+}
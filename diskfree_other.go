@@ -0,0 +1,6 @@
+//go:build !(linux || darwin || freebsd)
+
+package logger
+
+// diskFree is not supported on this platform, the free space guard never triggers.
+func diskFree(string) (uint64, bool) { return 0, false }
@@ -3,8 +3,10 @@ module github.com/thinkgos/logger
 go 1.24
 
 require (
+	github.com/klauspost/compress v1.18.0
 	go.uber.org/zap v1.28.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require go.uber.org/multierr v1.11.0 // indirect
@@ -0,0 +1,142 @@
+package grpclog_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/thinkgos/logger"
+	"github.com/thinkgos/logger/grpclog"
+)
+
+func newLogger(buf *bytes.Buffer) *logger.Log {
+	return logger.NewLogger(logger.WithLevel("debug"), logger.WithAdapter(logger.AdapterCustom, buf))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	interceptor := grpclog.UnaryServerInterceptor(newLogger(buf))
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Greeter/SayHello"}
+	_, err := interceptor(ctx, wrapperspb.String("hello"), info, func(ctx context.Context, req any) (any, error) {
+		logger.OnInfoContext(ctx).Msg("in handler")
+		return nil, status.Error(codes.Internal, "broken")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got: %s", buf.String())
+	}
+	if !strings.Contains(lines[0], `"grpc.service":"pkg.Greeter","grpc.method":"SayHello"`) {
+		t.Errorf("handler log should carry the method: %s", lines[0])
+	}
+	for _, want := range []string{`"level":"error"`, `"grpc.code":"Internal"`, `"grpc.peer":"127.0.0.1:5000"`, `"grpc.request_size":7`, `"grpc.duration":`, `"error":`} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("call log should contain %s: %s", want, lines[1])
+		}
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+func (s *fakeServerStream) SendMsg(any) error        { return nil }
+func (s *fakeServerStream) RecvMsg(any) error        { return nil }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	interceptor := grpclog.StreamServerInterceptor(newLogger(buf))
+
+	info := &grpc.StreamServerInfo{FullMethod: "/pkg.Chat/Talk", IsClientStream: true, IsServerStream: true}
+	err := interceptor(nil, &fakeServerStream{ctx: context.Background()}, info, func(srv any, ss grpc.ServerStream) error {
+		_ = ss.RecvMsg(wrapperspb.String("a"))
+		_ = ss.SendMsg(wrapperspb.String("bb"))
+		_ = ss.SendMsg(wrapperspb.String("cc"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"level":"info"`, `"grpc.code":"OK"`, `"grpc.stream":"bidi"`, `"grpc.recv_msgs":1`, `"grpc.sent_msgs":2`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("call log should contain %s: %s", want, buf.String())
+		}
+	}
+}
+
+func TestUnaryClientInterceptorInHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := newLogger(buf)
+	cc, err := grpc.NewClient("passthrough:///backend:9000", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	server := grpclog.UnaryServerInterceptor(log)
+	client := grpclog.UnaryClientInterceptor(log)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Greeter/SayHello"}
+	_, err = server(logger.WithFields(ctx, logger.String("request_id", "r1")), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, client(ctx, "/pkg.Backend/Get", nil, nil, cc, func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := strings.Split(strings.TrimSpace(buf.String()), "\n")[0]
+	for _, key := range []string{`"grpc.service"`, `"grpc.method"`, `"grpc.peer"`} {
+		if n := strings.Count(line, key); n != 1 {
+			t.Errorf("client log should contain %s once, got %d: %s", key, n, line)
+		}
+	}
+	for _, want := range []string{`"request_id":"r1"`, `"grpc.service":"pkg.Backend","grpc.method":"Get"`, `"grpc.peer":"passthrough:///backend:9000"`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("client log should contain %s: %s", want, line)
+		}
+	}
+}
+
+func TestLoggerV2(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := newLogger(buf)
+	l := grpclog.NewLoggerV2(log, grpclog.WithVerbosity(2))
+	l.Infoln("info", "line")
+	l.Warningf("warn %d", 1)
+	l.Error("error")
+	if !l.V(2) || l.V(3) {
+		t.Error("unexpected verbosity")
+	}
+	for _, want := range []string{`"logger":"grpc","msg":"info line"`, `"logger":"grpc","msg":"warn 1"`, `"logger":"grpc","msg":"error"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output should contain %s: %s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := log.SetNameLevel(grpclog.DefaultName, logger.ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+	l.Warning("suppressed")
+	if buf.Len() != 0 {
+		t.Errorf("the name level should be honored: %s", buf.String())
+	}
+}
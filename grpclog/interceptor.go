@@ -0,0 +1,273 @@
+// Package grpclog provides the gRPC interceptors which log the calls through logger.Log,
+// and a grpclog.LoggerV2 adapter which routes the internal logs of grpc-go to logger.Log.
+//
+// It is a separate module, so the logger module does not depend on gRPC.
+package grpclog
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/thinkgos/logger"
+)
+
+// field key defined
+const (
+	ServiceKey    = "grpc.service"
+	MethodKey     = "grpc.method"
+	PeerKey       = "grpc.peer"
+	CodeKey       = "grpc.code"
+	DurationKey   = "grpc.duration"
+	ReqSizeKey    = "grpc.request_size"
+	RespSizeKey   = "grpc.response_size"
+	RecvMsgsKey   = "grpc.recv_msgs"
+	SentMsgsKey   = "grpc.sent_msgs"
+	StreamKindKey = "grpc.stream"
+)
+
+type options struct {
+	message string
+	level   func(code codes.Code) logger.Level
+	skip    func(fullMethod string) bool
+}
+
+// Option the option of the interceptors.
+type Option func(o *options)
+
+// WithMessage set the message of the call log, default "grpc call".
+func WithMessage(msg string) Option {
+	return func(o *options) { o.message = msg }
+}
+
+// WithLevel set the level selection by status code, default DefaultServerLevel for the server interceptors,
+// DefaultClientLevel for the client interceptors.
+func WithLevel(f func(code codes.Code) logger.Level) Option {
+	return func(o *options) {
+		if f != nil {
+			o.level = f
+		}
+	}
+}
+
+// WithSkip set the filter, the call is not logged if it returns true, such as health check.
+func WithSkip(f func(fullMethod string) bool) Option {
+	return func(o *options) { o.skip = f }
+}
+
+func newOptions(level func(codes.Code) logger.Level, opts []Option) *options {
+	o := &options{message: "grpc call", level: level}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DefaultServerLevel maps the status code to the level for the server side.
+func DefaultServerLevel(code codes.Code) logger.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.Unauthenticated:
+		return logger.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted, codes.FailedPrecondition,
+		codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return logger.WarnLevel
+	default: // Unknown, Unimplemented, Internal, DataLoss
+		return logger.ErrorLevel
+	}
+}
+
+// DefaultClientLevel maps the status code to the level for the client side.
+func DefaultClientLevel(code codes.Code) logger.Level {
+	switch code {
+	case codes.OK:
+		return logger.DebugLevel
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.Unauthenticated,
+		codes.ResourceExhausted, codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return logger.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.Unavailable:
+		return logger.WarnLevel
+	default:
+		return logger.ErrorLevel
+	}
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor which logs the unary calls,
+// and attaches the logger and the method to the context, see logger.NewContext.
+func UnaryServerInterceptor(log *logger.Log, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(DefaultServerLevel, opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = newContext(ctx, log, info.FullMethod)
+		resp, err := handler(ctx, req)
+		if o.skip == nil || !o.skip(info.FullMethod) {
+			o.serverLog(ctx, log, start, err).
+				Int(ReqSizeKey, messageSize(req)).
+				Int(RespSizeKey, messageSize(resp)).
+				Msg(o.message)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor which logs the stream calls,
+// and attaches the logger and the method to the context of the stream, see logger.NewContext.
+func StreamServerInterceptor(log *logger.Log, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(DefaultServerLevel, opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := newContext(ss.Context(), log, info.FullMethod)
+		ws := &serverStream{ServerStream: ss, ctx: ctx}
+		err := handler(srv, ws)
+		if o.skip == nil || !o.skip(info.FullMethod) {
+			o.serverLog(ctx, log, start, err).
+				String(StreamKindKey, streamKind(info.IsClientStream, info.IsServerStream)).
+				Int(RecvMsgsKey, ws.recvMsgs).
+				Int(SentMsgsKey, ws.sentMsgs).
+				Int(ReqSizeKey, ws.recvSize).
+				Int(RespSizeKey, ws.sentSize).
+				Msg(o.message)
+		}
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor which logs the unary calls.
+func UnaryClientInterceptor(log *logger.Log, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(DefaultClientLevel, opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		if o.skip == nil || !o.skip(method) {
+			o.clientLog(ctx, log, method, cc.Target(), start, err).
+				Int(ReqSizeKey, messageSize(req)).
+				Int(RespSizeKey, messageSize(reply)).
+				Msg(o.message)
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor which logs the establishment of the stream.
+func StreamClientInterceptor(log *logger.Log, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(DefaultClientLevel, opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if o.skip == nil || !o.skip(method) {
+			o.clientLog(ctx, log, method, cc.Target(), start, err).
+				String(StreamKindKey, streamKind(desc.ClientStreams, desc.ServerStreams)).
+				Msg(o.message)
+		}
+		return cs, err
+	}
+}
+
+// serverLog starts the event of the server side, with the fields of the context and the peer.
+func (o *options) serverLog(ctx context.Context, log *logger.Log, start time.Time, err error) *logger.Event {
+	e := o.log(ctx, log, logger.FieldsFromContext(ctx), start, err)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e = e.String(PeerKey, p.Addr.String())
+	}
+	return e
+}
+
+// clientLog starts the event of the client side, with the client method and the target.
+// the call may be made inside a server handler, so the server method and peer in the context are dropped.
+func (o *options) clientLog(ctx context.Context, log *logger.Log, method, target string, start time.Time, err error) *logger.Event {
+	inherited := logger.FieldsFromContext(ctx)
+	fields := make([]logger.Field, 0, len(inherited)+2)
+	for _, f := range inherited {
+		if f.Key != ServiceKey && f.Key != MethodKey && f.Key != PeerKey {
+			fields = append(fields, f)
+		}
+	}
+	fields = append(fields, methodFields(method)...)
+	return o.log(ctx, log, fields, start, err).String(PeerKey, target)
+}
+
+// log starts the event with the common fields: the fields, code, duration and error.
+func (o *options) log(ctx context.Context, log *logger.Log, fields []logger.Field, start time.Time, err error) *logger.Event {
+	code := status.Code(err)
+	e := log.OnLevel(o.level(code)).
+		WithContext(ctx).
+		With(fields...).
+		String(CodeKey, code.String()).
+		Duration(DurationKey, time.Since(start))
+	if err != nil {
+		e = e.Error(err)
+	}
+	return e
+}
+
+func newContext(ctx context.Context, log *logger.Log, fullMethod string) context.Context {
+	return logger.WithFields(logger.NewContext(ctx, log), methodFields(fullMethod)...)
+}
+
+// methodFields splits the full method /package.service/method into the service and the method.
+func methodFields(fullMethod string) []logger.Field {
+	service, method := path.Split(fullMethod)
+	return []logger.Field{
+		logger.String(ServiceKey, path.Clean(service)[1:]),
+		logger.String(MethodKey, method),
+	}
+}
+
+func streamKind(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return "bidi"
+	case clientStream:
+		return "client"
+	case serverStream:
+		return "server"
+	default:
+		return "unary"
+	}
+}
+
+// messageSize returns the wire size of the proto message, 0 if not a proto message.
+func messageSize(m any) int {
+	if pm, ok := m.(proto.Message); ok {
+		return proto.Size(pm)
+	}
+	return 0
+}
+
+// serverStream wraps the grpc.ServerStream with the context, and counts the messages.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	recvMsgs int
+	sentMsgs int
+	recvSize int
+	sentSize int
+}
+
+// Context implements grpc.ServerStream.
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+// SendMsg implements grpc.ServerStream.
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sentMsgs++
+		s.sentSize += messageSize(m)
+	}
+	return err
+}
+
+// RecvMsg implements grpc.ServerStream.
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.recvMsgs++
+		s.recvSize += messageSize(m)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package grpclog
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/grpclog"
+
+	"github.com/thinkgos/logger"
+)
+
+// DefaultName the default logger name of LoggerV2, the level can be overridden by logger.Log.SetNameLevel.
+const DefaultName = "grpc"
+
+var _ grpclog.LoggerV2 = (*LoggerV2)(nil)
+
+// LoggerV2 is a grpclog.LoggerV2 backed by logger.Log,
+// Info -> info, Warning -> warn, Error -> error, Fatal -> fatal.
+//
+//	grpclog.SetLoggerV2(grpclog.NewLoggerV2(log))
+type LoggerV2 struct {
+	log       *logger.Log
+	verbosity int
+}
+
+type loggerV2Options struct {
+	name      string
+	verbosity int
+}
+
+// LoggerV2Option the option of LoggerV2.
+type LoggerV2Option func(o *loggerV2Options)
+
+// WithVerbosity set the verbosity, V(l) reports l <= verbosity, default 0.
+func WithVerbosity(verbosity int) LoggerV2Option {
+	return func(o *loggerV2Options) { o.verbosity = verbosity }
+}
+
+// WithName set the logger name, default "grpc", empty means not named.
+func WithName(name string) LoggerV2Option {
+	return func(o *loggerV2Options) { o.name = name }
+}
+
+// NewLoggerV2 new grpclog.LoggerV2 backed by the log, which named "grpc" by default.
+func NewLoggerV2(log *logger.Log, opts ...LoggerV2Option) *LoggerV2 {
+	o := &loggerV2Options{name: DefaultName}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.name != "" {
+		log = log.Named(o.name)
+	}
+	return &LoggerV2{log: log, verbosity: o.verbosity}
+}
+
+func (l *LoggerV2) Info(args ...any)                 { l.log.OnInfo().Print(args...) }
+func (l *LoggerV2) Infoln(args ...any)               { l.log.OnInfo().Msg(sprintln(args...)) }
+func (l *LoggerV2) Infof(format string, args ...any) { l.log.OnInfo().Printf(format, args...) }
+func (l *LoggerV2) Warning(args ...any)              { l.log.OnWarn().Print(args...) }
+func (l *LoggerV2) Warningln(args ...any)            { l.log.OnWarn().Msg(sprintln(args...)) }
+func (l *LoggerV2) Warningf(format string, args ...any) {
+	l.log.OnWarn().Printf(format, args...)
+}
+func (l *LoggerV2) Error(args ...any)                 { l.log.OnError().Print(args...) }
+func (l *LoggerV2) Errorln(args ...any)               { l.log.OnError().Msg(sprintln(args...)) }
+func (l *LoggerV2) Errorf(format string, args ...any) { l.log.OnError().Printf(format, args...) }
+func (l *LoggerV2) Fatal(args ...any)                 { l.log.OnFatal().Print(args...) }
+func (l *LoggerV2) Fatalln(args ...any)               { l.log.OnFatal().Msg(sprintln(args...)) }
+func (l *LoggerV2) Fatalf(format string, args ...any) { l.log.OnFatal().Printf(format, args...) }
+
+// V reports whether verbosity level l is at least the requested verbose level.
+func (l *LoggerV2) V(level int) bool { return level <= l.verbosity }
+
+func sprintln(args ...any) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
@@ -0,0 +1,243 @@
+// Package httplog provides a net/http middleware which emits one structured access log per request.
+//
+// The middleware attaches the logger and the request id to the request context,
+// so the OnXxxContext calls in the handlers carry the request id, see logger.NewContext and logger.WithFields.
+package httplog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/thinkgos/logger"
+)
+
+// DefaultRequestIDHeader the default header of the request id.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// field key defined
+const (
+	RequestIDKey = "request_id"
+	MethodKey    = "method"
+	PathKey      = "path"
+	RouteKey     = "route"
+	StatusKey    = "status"
+	BytesKey     = "bytes"
+	LatencyKey   = "latency"
+	RemoteIPKey  = "remote_ip"
+	UserAgentKey = "user_agent"
+)
+
+type options struct {
+	message         string
+	requestIDHeader string
+	requestID       func() string
+	trustedProxies  []netip.Prefix
+	level           func(status int) logger.Level
+	skip            func(r *http.Request) bool
+}
+
+// Option the option of the middleware.
+type Option func(o *options)
+
+// WithMessage set the message of the access log, default "access".
+func WithMessage(msg string) Option {
+	return func(o *options) { o.message = msg }
+}
+
+// WithRequestIDHeader set the header of the request id, default X-Request-Id.
+// the request id is taken from the request header if present, otherwise generated,
+// and set to the response header.
+func WithRequestIDHeader(header string) Option {
+	return func(o *options) {
+		if header != "" {
+			o.requestIDHeader = header
+		}
+	}
+}
+
+// WithRequestIDGenerator set the generator of the request id, default 16 bytes random hex.
+func WithRequestIDGenerator(f func() string) Option {
+	return func(o *options) {
+		if f != nil {
+			o.requestID = f
+		}
+	}
+}
+
+// WithTrustedProxies set the trusted proxies, the X-Forwarded-For header is only
+// honored when the request comes from the trusted proxies, default none.
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(o *options) { o.trustedProxies = prefixes }
+}
+
+// WithLevel set the level selection by status, default 5xx error, 4xx warn, others info.
+func WithLevel(f func(status int) logger.Level) Option {
+	return func(o *options) {
+		if f != nil {
+			o.level = f
+		}
+	}
+}
+
+// WithSkip set the filter, the request is not logged if it returns true, such as health check.
+func WithSkip(f func(r *http.Request) bool) Option {
+	return func(o *options) { o.skip = f }
+}
+
+// DefaultLevel 5xx error, 4xx warn, others info.
+func DefaultLevel(status int) logger.Level {
+	switch {
+	case status >= 500:
+		return logger.ErrorLevel
+	case status >= 400:
+		return logger.WarnLevel
+	default:
+		return logger.InfoLevel
+	}
+}
+
+// Middleware returns a net/http middleware which emits the access log through the log.
+func Middleware(log *logger.Log, opts ...Option) func(http.Handler) http.Handler {
+	o := &options{
+		message:         "access",
+		requestIDHeader: DefaultRequestIDHeader,
+		requestID:       newRequestID,
+		level:           DefaultLevel,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(o.requestIDHeader)
+			if requestID == "" {
+				requestID = o.requestID()
+			}
+			w.Header().Set(o.requestIDHeader, requestID)
+
+			ctx := logger.NewContext(r.Context(), log)
+			ctx = logger.WithFields(ctx, logger.String(RequestIDKey, requestID))
+			r = r.WithContext(ctx)
+
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			if o.skip != nil && o.skip(r) {
+				return
+			}
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			log.OnLevelContext(ctx, o.level(status)).
+				String(MethodKey, r.Method).
+				String(PathKey, r.URL.Path).
+				String(RouteKey, r.Pattern). // set by http.ServeMux
+				Int(StatusKey, status).
+				Int64(BytesKey, rw.bytes).
+				Duration(LatencyKey, time.Since(start)).
+				String(RemoteIPKey, o.remoteIP(r)).
+				String(UserAgentKey, r.UserAgent()).
+				Msg(o.message)
+		})
+	}
+}
+
+// Handler wraps the handler with the access log middleware, see Middleware.
+func Handler(log *logger.Log, next http.Handler, opts ...Option) http.Handler {
+	return Middleware(log, opts...)(next)
+}
+
+// remoteIP returns the client ip, the X-Forwarded-For header is walked from right to left
+// when the request comes from the trusted proxies, the first untrusted address is the client.
+func (o *options) remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !o.trusted(addr) {
+		return host
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		a, err := netip.ParseAddr(ip)
+		if err != nil {
+			break
+		}
+		host = a.String()
+		if !o.trusted(a) {
+			break
+		}
+	}
+	return host
+}
+
+func (o *options) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range o.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// responseWriter records the status and the bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("httplog: the response writer does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter, used by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,131 @@
+package httplog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/thinkgos/logger"
+	"github.com/thinkgos/logger/httplog"
+)
+
+func TestMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.OnInfoContext(r.Context()).Msg("in handler")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	})
+	h := httplog.Handler(log, mux, httplog.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.2")
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("User-Agent", "test-agent")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-Id"); got != "req-1" {
+		t.Fatalf("response request id = %q, want req-1", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got: %s", buf.String())
+	}
+	if !strings.Contains(lines[0], `"request_id":"req-1"`) {
+		t.Errorf("handler log should carry the request id: %s", lines[0])
+	}
+	var access map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &access); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"level":      "warn",
+		"msg":        "access",
+		"method":     "GET",
+		"path":       "/users/42",
+		"route":      "GET /users/{id}",
+		"status":     float64(404),
+		"bytes":      float64(9),
+		"remote_ip":  "203.0.113.9",
+		"user_agent": "test-agent",
+		"request_id": "req-1",
+	}
+	for k, v := range want {
+		if access[k] != v {
+			t.Errorf("%s = %v, want %v", k, access[k], v)
+		}
+	}
+	if _, ok := access["latency"]; !ok {
+		t.Error("latency should be logged")
+	}
+}
+
+func TestMiddleware_UntrustedProxy(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf))
+	h := httplog.Handler(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		httplog.WithSkip(func(r *http.Request) bool { return r.URL.Path == "/healthz" }),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("X-Request-Id") == "" {
+		t.Error("request id should be generated")
+	}
+	if !strings.Contains(buf.String(), `"remote_ip":"192.0.2.1"`) || !strings.Contains(buf.String(), `"status":200`) {
+		t.Errorf("unexpected access log: %s", buf.String())
+	}
+
+	buf.Reset()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if buf.Len() != 0 {
+		t.Errorf("skipped request should not be logged: %s", buf.String())
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf))
+	h := httplog.Handler(log, httplog.Recovery(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})), httplog.WithRequestIDGenerator(func() string { return "gen-1" }))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	out := buf.String()
+	for _, want := range []string{`"panic":"boom"`, `"stack":"`, "httplog_test.TestRecovery.func1", `"request_id":"gen-1"`, `"status":500`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %s: %s", want, out)
+		}
+	}
+
+	// the headers have been written, the status is kept.
+	buf.Reset()
+	h = httplog.Recovery(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("after header")
+	}))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", rec.Code)
+	}
+	if !strings.Contains(buf.String(), `"panic":"after header"`) {
+		t.Errorf("panic should be logged: %s", buf.String())
+	}
+}
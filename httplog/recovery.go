@@ -0,0 +1,42 @@
+package httplog
+
+import (
+	"net/http"
+
+	"github.com/thinkgos/logger"
+)
+
+// Recovery returns a net/http middleware which recovers the panic of the handler,
+// logs it at error level with the panic value, the stack and the request context fields,
+// and responds 500 Internal Server Error if the handler has not written the headers.
+// http.ErrAbortHandler is re-panicked without logging, it aborts the response.
+// Wrap it inside Middleware, so that the access log records the 500 status:
+//
+//	httplog.Handler(log, httplog.Recovery(log)(mux))
+func Recovery(log *logger.Log) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw, ok := w.(*responseWriter) // reuse the writer of Middleware, which records the status
+			if !ok {
+				rw = &responseWriter{ResponseWriter: w}
+			}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				log.LogPanic(r.Context(), v, logger.WithRecoverFields(
+					logger.String(MethodKey, r.Method),
+					logger.String(PathKey, r.URL.Path),
+				))
+				if rw.status == 0 { // the headers have not been sent yet
+					rw.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
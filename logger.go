@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -12,6 +14,10 @@ type Log struct {
 	hooks []Hook
 	// for caller
 	callerCore *CallerCore
+	// sinks built from Config, nil if constructed by NewLoggerWith
+	pipe *pipeline
+	// token buckets of Event.Every and Event.Sampled, shared with the children
+	limiter *rateLimiter
 }
 
 // NewLoggerWith new logger with zap logger and atomic level
@@ -21,6 +27,7 @@ func NewLoggerWith(logger *zap.Logger, lv AtomicLevel) *Log {
 		level:      lv,
 		hooks:      nil,
 		callerCore: NewCallerCore(),
+		limiter:    newRateLimiter(&SamplingConfig{}),
 	}
 }
 
@@ -30,25 +37,85 @@ func NewLoggerWith(logger *zap.Logger, lv AtomicLevel) *Log {
 // Level: 日志等级, 默认warn
 // Format: 编码格式, 默认json
 // EncodeLevel: 编码器类型, 默认LowercaseLevelEncoder
+// TimeKey: 时间字段名, 默认ts
+// TimeEncoding: 时间编码格式, rfc3339,rfc3339nano,iso8601,epoch,epoch-millis,epoch-nanos 或者自定义Go时间格式, 默认rfc3339
+// TimeZone: 时区, UTC,Local 或者IANA时区名, 默认使用系统时区
+// Clock: 日志时间来源, 默认系统时间
+// Encoder: 编码器字段名及调用者, 时间间隔, 名称编码格式, 调用者默认auto, 日志等级为debug时使用完整路径
 // Adapter: 默认输出适合器, 默认console`
+// Sinks: 输出端列表, 如果配置该项, 则 Adapter 将被覆盖
+// NameLevels: 按logger名称覆盖日志等级, 默认空
 // Stack: 是否使能栈调试输出, 默认false
+// Async: 异步写入配置, 同步编码, 有界缓冲区满时按 Overflow 策略处理, 默认同步写入
+// Sampling: 日志采样(Initial/Thereafter/Tick)及 Event.Sampled 限流(Rate/Burst)配置, 默认不采样
+// Dedup: 重复日志折叠配置, 窗口内连续重复的日志汇总为一条带repeated计数的日志, 默认不折叠
+// Redact: 敏感字段脱敏配置, 按字段名, 字段名通配符及值正则匹配, 默认不脱敏
 // Path: 日志保存路径, 默认当前路径
 // Writer: 当adapter有附带custom时, 如果为writer为空, 将使用os.Stdout
-// EncoderConfig: 如果配置该项,则 EncodeLevel 将被覆盖
+// EncoderConfig: 如果配置该项,则 EncodeLevel, TimeKey, TimeEncoding, TimeZone 及 Encoder 将被覆盖
+// Console/Custom: 控制台/自定义输出独立的日志等级和编码格式, 默认不额外过滤, 使用Format
 //
 // 文件日志切割配置(启用file时生效)
 // Filename 空字符使用默认, 默认<processname>-lumberjack.log
 // MaxSize 每个日志文件最大尺寸(MB), 默认100MB
 // MaxAge 日志文件保存天数, 默认0 不删除
 // MaxBackups 日志文件保存备份数, 默认0 都保存
+// MaxTotalSize 日志文件和备份的总尺寸上限(MB), 默认0 不限制
+// MinFreeSpace 磁盘最小可用空间(MB), 默认0 不检查
+// LowSpacePolicy 磁盘可用空间不足时的处理策略: drop,stop, 默认drop
 // LocalTime 是否格式化时间戳, 默认UTC时间
 // Compress 是否使用gzip压缩文件, 采用默认不压缩
+// Compression 切割后的文件压缩算法: none,gzip,zstd, 可附带压缩等级, 默认空, 使用Compress
+// Rotation 按时间切割: hourly,daily 或者时间间隔, 默认空, 仅按大小切割
+// Pattern 文件名模板, 支持strftime风格的占位符, 如 app-%Y-%m-%d.log, 默认空, 使用Filename
+// Level 文件输出独立的日志等级, 默认不额外过滤
+// Format 文件输出独立的编码格式, 默认使用Format
 //
 // Caller相关
 // callerLevel caller日志级别, 默认warn
 // callerSkip caller设置跳过深度, 默认0
 // callerSkipPackages caller设置跳过的包名, 默认空
-func NewLogger(opts ...Option) *Log { return NewLoggerWith(New(opts...)) }
+func NewLogger(opts ...Option) *Log {
+	log, _ := newLog(newConfig(opts...))
+	return log
+}
+
+// NewE new logger, same as NewLogger, but it validates the config first
+// instead of falling back to defaults silently, see Config.Validate.
+// It reports every invalid field, and the error of building sinks.
+func NewE(opts ...Option) (*Log, error) {
+	c := newConfig(opts...)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	log, err := newLog(c)
+	if err != nil {
+		_ = log.Close(context.Background()) // release the sinks which have been built
+		return nil, err
+	}
+	return log, nil
+}
+
+func newLog(c *Config) (*Log, error) {
+	l, lv, pipe, err := newWithConfig(c)
+	log := NewLoggerWith(l, lv)
+	log.pipe = pipe
+	log.limiter = pipe.limiter
+	log.applyCallerLevel(c.CallerLevel)
+	return log, err
+}
+
+// applyCallerLevel set the caller level if the level is valid, empty means the default level,
+// so the caller level is reset if it is removed from the reloaded config.
+func (l *Log) applyCallerLevel(level string) {
+	if level == "" {
+		l.callerCore.SetLevel(defaultCallerLevel)
+		return
+	}
+	if lv, err := zapcore.ParseLevel(level); err == nil {
+		l.callerCore.SetLevel(lv)
+	}
+}
 
 // SetNewCallerCore overwrite with new caller core
 func (l *Log) SetNewCallerCore(c *CallerCore) *Log {
@@ -119,12 +186,31 @@ func (l *Log) SetLevel(lv Level) *Log {
 // GetLevel returns the minimum enabled log level.
 func (l *Log) GetLevel() Level { return l.level.Level() }
 
-// Enabled returns true if the given level is at or above this level.
-func (l *Log) Enabled(lvl Level) bool { return l.level.Enabled(lvl) }
+// Enabled returns true if the given level is at or above this level,
+// the override level of the logger name takes precedence, see SetNameLevel.
+func (l *Log) Enabled(lvl Level) bool {
+	if l.pipe != nil {
+		if lv, ok := l.pipe.names.match(l.log.Name()); ok {
+			return lv.Enabled(lvl)
+		}
+	}
+	return l.level.Enabled(lvl)
+}
 
 // V returns true if the given level is at or above this level.
 // same as Enabled
-func (l *Log) V(lvl Level) bool { return l.level.Enabled(lvl) }
+func (l *Log) V(lvl Level) bool { return l.Enabled(lvl) }
+
+// SinkLevel returns the level of the named sink, see SinkConsole, SinkFile, SinkCustom.
+// The sink level only works on the sink, and the event must be enabled by the logger level first.
+func (l *Log) SinkLevel(name string) (AtomicLevel, bool) {
+	return l.pipe.sinkLevel(name)
+}
+
+// SinkLevels returns the levels of all sinks, keyed by sink name.
+func (l *Log) SinkLevels() map[string]AtomicLevel {
+	return l.pipe.sinkLevels()
+}
 
 // Sugar wraps the Logger to provide a more ergonomic, but slightly slower,
 // API. Sugaring a Logger is quite inexpensive, so it's reasonable for a
@@ -176,6 +262,8 @@ func (l *Log) ExtendHook(hs ...Hook) *Log {
 		level:      l.level,
 		hooks:      hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -191,6 +279,8 @@ func (l *Log) ExtendHookFunc(hs ...HookFunc) *Log {
 		level:      l.level,
 		hooks:      hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -206,6 +296,8 @@ func (l *Log) ExtendHookField(hs ...HookField) *Log {
 		level:      l.level,
 		hooks:      hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -218,6 +310,8 @@ func (l *Log) WithNewHook(hs ...Hook) *Log {
 		level:      l.level,
 		hooks:      hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -232,6 +326,8 @@ func (l *Log) WithNewHookFunc(hs ...HookFunc) *Log {
 		level:      l.level,
 		hooks:      hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -246,6 +342,8 @@ func (l *Log) WithNewHookField(hs ...HookField) *Log {
 		level:      l.level,
 		hooks:      hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -259,6 +357,8 @@ func (l *Log) With(fields ...Field) *Log {
 		level:      l.level,
 		hooks:      l.hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -269,6 +369,8 @@ func (l *Log) Named(name string) *Log {
 		level:      l.level,
 		hooks:      l.hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
@@ -280,6 +382,8 @@ func (l *Log) clone() *Log {
 		level:      l.level,
 		hooks:      l.hooks,
 		callerCore: l.callerCore,
+		pipe:       l.pipe,
+		limiter:    l.limiter,
 	}
 }
 
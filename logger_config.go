@@ -1,8 +1,18 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/thinkgos/logger/rotate"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -19,10 +29,154 @@ type LumberjackFile struct {
 	MaxAge int `yaml:"maxAge" json:"maxAge"`
 	// MaxBackups 日志文件保存备份数, 默认0 都保存
 	MaxBackups int `yaml:"maxBackups" json:"maxBackups"`
+	// MaxTotalSize 当前日志文件和所有备份(包括压缩)的总尺寸上限(MB), 超过时删除最旧的备份, 默认0 不限制
+	MaxTotalSize int `yaml:"maxTotalSize" json:"maxTotalSize"`
+	// MinFreeSpace 日志所在磁盘的最小可用空间(MB), 低于该值时按 LowSpacePolicy 处理, 并通过其它输出端(没有时使用os.Stderr)输出一次警告, 默认0 不检查
+	MinFreeSpace int `yaml:"minFreeSpace" json:"minFreeSpace"`
+	// LowSpacePolicy 磁盘可用空间不足时的处理策略, drop: 丢弃error以下等级的日志, stop: 停止写入, 默认drop
+	LowSpacePolicy string `yaml:"lowSpacePolicy" json:"lowSpacePolicy"`
 	// LocalTime 是否格式化时间戳, 默认UTC时间
 	LocalTime bool `yaml:"localTime" json:"localTime"`
 	// Compress 是否使用gzip压缩文件, 采用默认不压缩
 	Compress bool `yaml:"compress" json:"compress"`
+	// Compression 切割后的文件压缩算法, 格式为 name[:level], 配置后 Compress 将被忽略
+	// none: 不压缩
+	// gzip: gzip压缩, gzip:1 - gzip:9 指定压缩等级
+	// zstd: zstd压缩, zstd:1 - zstd:22 指定压缩等级
+	Compression string `yaml:"compression" json:"compression"`
+	// Rotation 按时间切割: hourly,daily 或者时间间隔如 30m,12h, 默认空, 仅按大小切割
+	// 配置后同时按时间和大小(MaxSize)切割
+	Rotation string `yaml:"rotation" json:"rotation"`
+	// Pattern 文件名模板, 支持strftime风格的占位符: %Y,%y,%m,%d,%H,%M,%S,%j,%%, 仅允许在文件名中使用
+	// 如 app-%Y-%m-%d.log, 使用当前切割周期的开始时间格式化, 配置后 Filename 将被忽略
+	Pattern string `yaml:"pattern" json:"pattern"`
+	// Level 文件输出的最低日志等级, 空表示不额外过滤, 仅受 Config.Level 约束
+	Level string `yaml:"level" json:"level"`
+	// Format 文件输出的编码格式: json,console 空表示使用 Config.Format
+	Format string `yaml:"format" json:"format"`
+}
+
+// AdapterConfig 输出适配器独立配置
+type AdapterConfig struct {
+	// Level 该输出的最低日志等级, 空表示不额外过滤, 仅受 Config.Level 约束
+	Level string `yaml:"level" json:"level"`
+	// Format 该输出的编码格式: json,console 空表示使用 Config.Format
+	Format string `yaml:"format" json:"format"`
+}
+
+// AsyncConfig 异步写入配置, 日志同步编码, 通过有界环形缓冲区由后台异步写入输出端
+type AsyncConfig struct {
+	// Enable 是否启用异步写入, 默认false
+	Enable bool `yaml:"enable" json:"enable"`
+	// Size 每个输出端的缓冲区大小(条), 默认1024
+	Size int `yaml:"size" json:"size"`
+	// FlushInterval 定时刷新间隔, 如 100ms,1s, 默认1s, 缓冲区半满时也会立即刷新
+	FlushInterval string `yaml:"flushInterval" json:"flushInterval"`
+	// Overflow 缓冲区满时的策略, 默认block
+	// block: 阻塞等待
+	// drop-newest: 丢弃新的日志
+	// drop-oldest: 丢弃缓冲区中最旧的日志
+	// drop-below: 丢弃低于 DropLevel 的新日志, 其它阻塞等待
+	Overflow string `yaml:"overflow" json:"overflow"`
+	// DropLevel Overflow为drop-below时有效, 默认error
+	DropLevel string `yaml:"dropLevel" json:"dropLevel"`
+}
+
+// SamplingConfig 日志采样及限流配置
+type SamplingConfig struct {
+	// Initial 每个Tick内, 相同等级和消息的日志前Initial条全部输出, 默认0 不采样
+	Initial int `yaml:"initial" json:"initial"`
+	// Thereafter 超过Initial后, 每Thereafter条输出一条, 默认0 超过Initial后全部丢弃
+	Thereafter int `yaml:"thereafter" json:"thereafter"`
+	// Tick 采样周期, 如 100ms,1s, 默认1s
+	Tick string `yaml:"tick" json:"tick"`
+	// Rate Event.Sampled 每个key每秒允许输出的日志条数, 默认1
+	Rate float64 `yaml:"rate" json:"rate"`
+	// Burst Event.Sampled 每个key允许的突发条数, 默认1
+	Burst int `yaml:"burst" json:"burst"`
+}
+
+// DedupConfig 重复日志折叠配置
+// 窗口内连续相同(logger名称, 等级, 消息, 字段)的日志只输出第一条,
+// 后续重复的日志在出现不同的日志, 窗口结束或者Sync时输出一条带 repeated, first, last 字段的汇总日志
+type DedupConfig struct {
+	// Enable 是否启用, 默认false
+	Enable bool `yaml:"enable" json:"enable"`
+	// Window 折叠窗口, 如 100ms,1s, 默认1s
+	Window string `yaml:"window" json:"window"`
+}
+
+// RedactConfig 敏感字段脱敏配置, 在编码前处理, 包括嵌套的 Dict/Object/Reflect 字段及 slog 属性
+// Action 及 Mask 同时作用于 Struct 中带 log:",redact" 标签的字段
+type RedactConfig struct {
+	// Keys 敏感字段名, 不区分大小写, 如 password,token
+	// 带点的字段名(如 slog 分组属性 req.password)同时匹配全名及最后一段
+	Keys []string `yaml:"keys" json:"keys"`
+	// Patterns 敏感字段名通配符, 不区分大小写, 如 *token*,*secret
+	Patterns []string `yaml:"patterns" json:"patterns"`
+	// Values 敏感值正则表达式, 内置: credit-card(需通过Luhn校验),bearer,email
+	Values []string `yaml:"values" json:"values"`
+	// Action 处理方式: mask,hash,drop, 默认mask
+	// mask: 替换为 Mask
+	// hash: 替换为 sha256 哈希前缀
+	// drop: 丢弃该字段
+	Action string `yaml:"action" json:"action"`
+	// Mask 替换文本, 默认******
+	Mask string `yaml:"mask" json:"mask"`
+}
+
+// EncoderSettings 可序列化的编码器配置, 字段名空表示使用默认值, "-" 表示不输出该字段
+// 时间字段名及格式见 Config.TimeKey, Config.TimeEncoding, Config.TimeZone
+type EncoderSettings struct {
+	// MessageKey 消息字段名, 默认msg
+	MessageKey string `yaml:"messageKey" json:"messageKey"`
+	// LevelKey 日志等级字段名, 默认level
+	LevelKey string `yaml:"levelKey" json:"levelKey"`
+	// NameKey logger名称字段名, 默认logger
+	NameKey string `yaml:"nameKey" json:"nameKey"`
+	// CallerKey 调用者字段名, 默认caller
+	CallerKey string `yaml:"callerKey" json:"callerKey"`
+	// FunctionKey 调用函数字段名, 默认不输出
+	FunctionKey string `yaml:"functionKey" json:"functionKey"`
+	// StacktraceKey 栈字段名, 默认stacktrace
+	StacktraceKey string `yaml:"stacktraceKey" json:"stacktraceKey"`
+	// LineEnding 行结束符, 默认\n
+	LineEnding string `yaml:"lineEnding" json:"lineEnding"`
+	// CallerEncoding 调用者编码格式, 默认auto
+	// auto: 日志等级为debug时使用full, 否则使用short
+	// short: 包名/文件名:行号
+	// full: 完整文件路径:行号
+	// package: 包导入路径/文件名:行号
+	CallerEncoding string `yaml:"callerEncoding" json:"callerEncoding"`
+	// DurationEncoding 时间间隔编码格式: string,seconds,millis,nanos, 默认string
+	DurationEncoding string `yaml:"durationEncoding" json:"durationEncoding"`
+	// NameEncoding logger名称编码格式, 默认full
+	// full: 完整名称, 如 db.query
+	// short: 最后一段名称, 如 query
+	NameEncoding string `yaml:"nameEncoding" json:"nameEncoding"`
+}
+
+// SinkConfig 输出端配置
+type SinkConfig struct {
+	// Name 输出端名称, 用于运行时获取该输出端的等级, 空表示使用Type
+	Name string `yaml:"name" json:"name"`
+	// Type 输出端类型: stdout,stderr,file,rotate,custom 默认 stdout
+	// file: 普通文件, 仅使用 File.Path 和 File.Filename
+	// rotate: 按大小切割的文件, 使用 File 配置
+	// custom: 如果配置了 Sink, 使用注册的输出端, 否则使用 Config.Writer, 如果为空, 将使用os.Stdout
+	Type string `yaml:"type" json:"type"`
+	// Sink 通过 RegisterSink 注册的输出端名称, Type为custom时有效
+	Sink string `yaml:"sink" json:"sink"`
+	// Params 注册的输出端参数, 传递给注册的 SinkFactory
+	Params map[string]any `yaml:"params" json:"params"`
+	// Format 编码格式: json,console 空表示使用 Config.Format
+	Format string `yaml:"format" json:"format"`
+	// EncodeLevel 编码器类型, 空表示使用 Config.EncodeLevel
+	EncodeLevel string `yaml:"encodeLevel" json:"encodeLevel"`
+	// Level 最低日志等级, 空表示不额外过滤, 仅受 Config.Level 约束
+	Level string `yaml:"level" json:"level"`
+	// File 文件配置, Type为file,rotate时有效, 忽略其中的 Level 和 Format
+	File LumberjackFile `yaml:"file" json:"file"`
 }
 
 // Config 日志配置
@@ -37,17 +191,55 @@ type Config struct {
 	// CapitalLevelEncoder: 大写编码器
 	// CapitalColorLevelEncoder: 大写编码器带颜色
 	EncodeLevel string `yaml:"encodeLevel" json:"encodeLevel"`
+	// TimeKey 时间字段名, 默认ts, "-" 表示不输出时间
+	TimeKey string `yaml:"timeKey" json:"timeKey"`
+	// TimeEncoding 时间编码格式, 默认rfc3339
+	// rfc3339: 2006-01-02T15:04:05Z07:00
+	// rfc3339nano: 2006-01-02T15:04:05.999999999Z07:00
+	// iso8601: 2006-01-02T15:04:05.000Z0700
+	// epoch: 秒级浮点时间戳
+	// epoch-millis: 毫秒级浮点时间戳
+	// epoch-nanos: 纳秒级整数时间戳
+	// 其它: 自定义Go时间格式, 如 2006-01-02 15:04:05.000
+	TimeEncoding string `yaml:"timeEncoding" json:"timeEncoding"`
+	// TimeZone 时区, UTC,Local 或者IANA时区名, 如 Asia/Shanghai, 默认空, 使用系统时区
+	TimeZone string `yaml:"timeZone" json:"timeZone"`
 	// Adapter 输出适配器, file,console,multi,custom,file-custom,console-custom,multi-custom 默认 console
+	// 当配置了 Sinks 时, 该项及 File, Console, Custom 将被忽略
 	Adapter string `yaml:"adapter" json:"adapter"`
+	// Sinks 输出端列表, 如果配置该项, 则 Adapter 将被覆盖
+	Sinks []SinkConfig `yaml:"sinks" json:"sinks"`
 	// Stack 是否使能栈调试输出, 默认false
 	Stack bool `yaml:"stack" json:"stack"`
+	// NameLevels 按logger名称覆盖日志等级, 见 Log.Named
+	// key: 名称, 如 db, 或者前缀通配, 如 db.* 匹配 db 下的所有子logger
+	// value: 日志等级, debug,info,warn,error,dpanic,panic,fatal
+	NameLevels map[string]string `yaml:"nameLevels" json:"nameLevels"`
+	// CallerLevel caller日志级别, debug,info,warn,error,dpanic,panic,fatal, 空表示使用 CallerCore 的默认值error
+	CallerLevel string `yaml:"callerLevel" json:"callerLevel"`
 	// Writer 输出
 	// 当adapter有附带custom时, 如果为writer为空, 将使用os.Stdout
 	Writer []io.Writer `yaml:"-" json:"-"`
-	// EncoderConfig 如果配置该项,则 EncodeLevel 将被覆盖
+	// Encoder 可序列化的编码器配置, 字段名, 行结束符, 调用者, 时间间隔及名称编码格式
+	Encoder EncoderSettings `yaml:"encoder" json:"encoder"`
+	// EncoderConfig 如果配置该项,则 EncodeLevel, TimeKey, TimeEncoding, TimeZone 及 Encoder 将被覆盖
 	EncoderConfig *zapcore.EncoderConfig `yaml:"-" json:"-"`
+	// Clock 日志时间来源, 默认系统时间, 一般用于测试注入固定时间
+	Clock zapcore.Clock `yaml:"-" json:"-"`
+	// Async 异步写入配置, 默认同步写入
+	Async AsyncConfig `yaml:"async" json:"async"`
+	// Sampling 日志采样及限流配置, 默认不采样
+	Sampling SamplingConfig `yaml:"sampling" json:"sampling"`
+	// Dedup 重复日志折叠配置, 默认不折叠
+	Dedup DedupConfig `yaml:"dedup" json:"dedup"`
+	// Redact 敏感字段脱敏配置, 默认不脱敏
+	Redact RedactConfig `yaml:"redact" json:"redact"`
 	// 文件配置, 仅Adapter有file时有效
 	File LumberjackFile `yaml:"file" json:"file"`
+	// Console 控制台输出配置, 仅Adapter有console时有效
+	Console AdapterConfig `yaml:"console" json:"console"`
+	// Custom 自定义输出配置, 仅Adapter有custom时有效
+	Custom AdapterConfig `yaml:"custom" json:"custom"`
 }
 
 // Option An Option configures a Log.
@@ -94,6 +286,105 @@ func WithAdapter(adapter string, writer ...io.Writer) Option {
 	}
 }
 
+// WithAdapterLevel with adapter level
+// adapter: console,file,custom
+// level: 该输出的最低日志等级, 空表示不额外过滤
+func WithAdapterLevel(adapter, level string) Option {
+	return func(c *Config) {
+		if lv, _ := c.adapterConfig(adapter); lv != nil {
+			*lv = level
+		}
+	}
+}
+
+// WithAdapterFormat with adapter format
+// adapter: console,file,custom
+// format: json,console 空表示使用 Config.Format
+func WithAdapterFormat(adapter, format string) Option {
+	return func(c *Config) {
+		if _, f := c.adapterConfig(adapter); f != nil {
+			*f = format
+		}
+	}
+}
+
+// WithSinks with sinks
+// 如果配置该项, 则 Adapter 将被覆盖
+func WithSinks(sinks ...SinkConfig) Option {
+	return func(c *Config) { c.Sinks = append(c.Sinks, sinks...) }
+}
+
+// WithNameLevel with name level
+// 按logger名称覆盖日志等级, pattern: 名称, 如 db, 或者前缀通配, 如 db.*
+func WithNameLevel(pattern, level string) Option {
+	return func(c *Config) {
+		if c.NameLevels == nil {
+			c.NameLevels = make(map[string]string)
+		}
+		c.NameLevels[pattern] = level
+	}
+}
+
+// WithCallerLevel with caller level
+// caller日志级别, 空表示使用 CallerCore 的默认值error
+func WithCallerLevel(level string) Option {
+	return func(c *Config) { c.CallerLevel = level }
+}
+
+// WithTimeFieldKey with time key
+// 时间字段名, 默认ts, "-" 表示不输出时间
+func WithTimeFieldKey(key string) Option {
+	return func(c *Config) { c.TimeKey = key }
+}
+
+// WithTimeEncoding with time encoding
+// 时间编码格式: rfc3339,rfc3339nano,iso8601,epoch,epoch-millis,epoch-nanos 或者自定义Go时间格式, 默认rfc3339
+func WithTimeEncoding(encoding string) Option {
+	return func(c *Config) { c.TimeEncoding = encoding }
+}
+
+// WithTimeZone with time zone
+// 时区: UTC,Local 或者IANA时区名, 如 Asia/Shanghai, 默认使用系统时区
+func WithTimeZone(zone string) Option {
+	return func(c *Config) { c.TimeZone = zone }
+}
+
+// WithEncoderSettings with encoder settings
+// 可序列化的编码器配置, 见 EncoderSettings
+func WithEncoderSettings(settings EncoderSettings) Option {
+	return func(c *Config) { c.Encoder = settings }
+}
+
+// WithClock with clock
+// 日志时间来源, 默认系统时间
+func WithClock(clock zapcore.Clock) Option {
+	return func(c *Config) { c.Clock = clock }
+}
+
+// WithAsync with async
+// 异步写入配置, 默认同步写入
+func WithAsync(async AsyncConfig) Option {
+	return func(c *Config) { c.Async = async }
+}
+
+// WithSampling with sampling
+// 日志采样及限流配置, 默认不采样
+func WithSampling(sampling SamplingConfig) Option {
+	return func(c *Config) { c.Sampling = sampling }
+}
+
+// WithDedup with dedup
+// 重复日志折叠配置, 默认不折叠
+func WithDedup(dedup DedupConfig) Option {
+	return func(c *Config) { c.Dedup = dedup }
+}
+
+// WithRedact with redact
+// 敏感字段脱敏配置, 默认不脱敏
+func WithRedact(redact RedactConfig) Option {
+	return func(c *Config) { c.Redact = redact }
+}
+
 // WithStack with stack
 // Stack 是否使能栈调试输出, 默认false
 func WithStack(stack bool) Option {
@@ -132,6 +423,21 @@ func WithMaxBackups(maxBackups int) Option {
 	return func(c *Config) { c.File.MaxBackups = maxBackups }
 }
 
+// WithMaxTotalSize with max total size
+// 当前日志文件和所有备份的总尺寸上限(MB), 默认0 不限制
+func WithMaxTotalSize(maxTotalSize int) Option {
+	return func(c *Config) { c.File.MaxTotalSize = maxTotalSize }
+}
+
+// WithMinFreeSpace with min free space
+// 日志所在磁盘的最小可用空间(MB), policy: drop(默认),stop
+func WithMinFreeSpace(minFreeSpace int, policy string) Option {
+	return func(c *Config) {
+		c.File.MinFreeSpace = minFreeSpace
+		c.File.LowSpacePolicy = policy
+	}
+}
+
 // WithEnableLocalTime with local time
 // 是否格式化时间戳, 默认UTC时间
 func WithEnableLocalTime() Option {
@@ -143,3 +449,305 @@ func WithEnableLocalTime() Option {
 func WithEnableCompress() Option {
 	return func(c *Config) { c.File.Compress = true }
 }
+
+// WithCompression with compression
+// 切割后的文件压缩算法: none,gzip,zstd, 可附带压缩等级, 如 zstd:3, 配置后 Compress 将被忽略
+func WithCompression(compression string) Option {
+	return func(c *Config) { c.File.Compression = compression }
+}
+
+// WithRotation with rotation
+// 按时间切割: hourly,daily 或者时间间隔如 30m,12h, 默认空, 仅按大小切割
+func WithRotation(rotation string) Option {
+	return func(c *Config) { c.File.Rotation = rotation }
+}
+
+// WithPattern with pattern
+// 文件名模板, 支持strftime风格的占位符, 如 app-%Y-%m-%d.log, 配置后 Filename 将被忽略
+func WithPattern(pattern string) Option {
+	return func(c *Config) { c.File.Pattern = pattern }
+}
+
+// adapterConfig returns the level and format of the adapter.
+func (c *Config) adapterConfig(adapter string) (level, format *string) {
+	switch adapter {
+	case AdapterConsole:
+		return &c.Console.Level, &c.Console.Format
+	case AdapterFile:
+		return &c.File.Level, &c.File.Format
+	case AdapterCustom:
+		return &c.Custom.Level, &c.Custom.Format
+	default:
+		return nil, nil
+	}
+}
+
+// ConfigError describes an invalid field of Config.
+type ConfigError struct {
+	Field  string // field path, same as the yaml tag, e.g. sinks[0].level
+	Value  any    // invalid value
+	Reason string // why the value is invalid
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("logger: invalid config %s=%v: %s", e.Field, e.Value, e.Reason)
+}
+
+// Validate validates the config and reports every invalid field with field name.
+// The returned error joins all the *ConfigError, use errors.As to inspect it.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field string, value any, reason string) {
+		errs = append(errs, &ConfigError{Field: field, Value: value, Reason: reason})
+	}
+	if _, err := zapcore.ParseLevel(c.Level); err != nil {
+		invalid("level", c.Level, "unrecognized level")
+	}
+	if !validFormat(c.Format) {
+		invalid("format", c.Format, "must be one of json,console")
+	}
+	if !validSinkLevel(c.CallerLevel) {
+		invalid("callerLevel", c.CallerLevel, "unrecognized level")
+	}
+	if _, err := toLocation(c.TimeZone); err != nil {
+		invalid("timeZone", c.TimeZone, "unknown time zone")
+	}
+	switch c.Encoder.CallerEncoding {
+	case "", CallerEncodingAuto, CallerEncodingShort, CallerEncodingFull, CallerEncodingPackage:
+	default:
+		invalid("encoder.callerEncoding", c.Encoder.CallerEncoding, "must be one of auto,short,full,package")
+	}
+	switch c.Encoder.DurationEncoding {
+	case "", DurationEncodingString, DurationEncodingSeconds, DurationEncodingMillis, DurationEncodingNanos:
+	default:
+		invalid("encoder.durationEncoding", c.Encoder.DurationEncoding, "must be one of string,seconds,millis,nanos")
+	}
+	switch c.Encoder.NameEncoding {
+	case "", NameEncodingFull, NameEncodingShort:
+	default:
+		invalid("encoder.nameEncoding", c.Encoder.NameEncoding, "must be one of full,short")
+	}
+	if c.Async.Size < 0 {
+		invalid("async.size", c.Async.Size, "must not be negative")
+	}
+	if c.Async.FlushInterval != "" {
+		if d, err := time.ParseDuration(c.Async.FlushInterval); err != nil || d <= 0 {
+			invalid("async.flushInterval", c.Async.FlushInterval, "must be a positive duration")
+		}
+	}
+	switch c.Async.Overflow {
+	case "", OverflowBlock, OverflowDropNewest, OverflowDropOldest, OverflowDropBelow:
+	default:
+		invalid("async.overflow", c.Async.Overflow, "must be one of block,drop-newest,drop-oldest,drop-below")
+	}
+	if !validSinkLevel(c.Async.DropLevel) {
+		invalid("async.dropLevel", c.Async.DropLevel, "unrecognized level")
+	}
+	if c.Sampling.Initial < 0 {
+		invalid("sampling.initial", c.Sampling.Initial, "must not be negative")
+	}
+	if c.Sampling.Thereafter < 0 {
+		invalid("sampling.thereafter", c.Sampling.Thereafter, "must not be negative")
+	}
+	if c.Sampling.Tick != "" {
+		if d, err := time.ParseDuration(c.Sampling.Tick); err != nil || d <= 0 {
+			invalid("sampling.tick", c.Sampling.Tick, "must be a positive duration")
+		}
+	}
+	if c.Sampling.Rate < 0 {
+		invalid("sampling.rate", c.Sampling.Rate, "must not be negative")
+	}
+	if c.Sampling.Burst < 0 {
+		invalid("sampling.burst", c.Sampling.Burst, "must not be negative")
+	}
+	if c.Dedup.Window != "" {
+		if d, err := time.ParseDuration(c.Dedup.Window); err != nil || d <= 0 {
+			invalid("dedup.window", c.Dedup.Window, "must be a positive duration")
+		}
+	}
+	switch c.Redact.Action {
+	case "", RedactMask, RedactHash, RedactDrop:
+	default:
+		invalid("redact.action", c.Redact.Action, "must be one of mask,hash,drop")
+	}
+	for i, pattern := range c.Redact.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			invalid(fmt.Sprintf("redact.patterns[%d]", i), pattern, err.Error())
+		}
+	}
+	for i, value := range c.Redact.Values {
+		if _, ok := builtinRedactValues[value]; ok {
+			continue
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			invalid(fmt.Sprintf("redact.values[%d]", i), value, err.Error())
+		}
+	}
+	for pattern, level := range c.NameLevels {
+		if err := validNamePattern(pattern); err != nil {
+			invalid("nameLevels", pattern, "invalid name pattern")
+		}
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			invalid("nameLevels."+pattern, level, "unrecognized level")
+		}
+	}
+	if !validEncodeLevel(c.EncodeLevel) {
+		invalid("encodeLevel", c.EncodeLevel, "unrecognized level encoder")
+	}
+	if len(c.Sinks) == 0 {
+		switch strings.ToLower(c.Adapter) {
+		case "", AdapterConsole, AdapterFile, AdapterMulti, AdapterCustom,
+			AdapterConsoleCustom, AdapterFileCustom, AdapterMultiCustom:
+		default:
+			invalid("adapter", c.Adapter, "unrecognized adapter")
+		}
+		validAdapterConfig := func(field string, ac AdapterConfig) {
+			if !validSinkLevel(ac.Level) {
+				invalid(field+".level", ac.Level, "unrecognized level")
+			}
+			if !validFormat(ac.Format) {
+				invalid(field+".format", ac.Format, "must be one of json,console")
+			}
+		}
+		validAdapterConfig("console", c.Console)
+		validAdapterConfig("custom", c.Custom)
+		validAdapterConfig("file", AdapterConfig{Level: c.File.Level, Format: c.File.Format})
+		if strings.Contains(strings.ToLower(c.Adapter), AdapterFile) ||
+			strings.HasPrefix(strings.ToLower(c.Adapter), AdapterMulti) {
+			errs = append(errs, c.File.validate("file")...)
+		}
+	}
+
+	names := make(map[string]struct{}, len(c.Sinks))
+	for i, sc := range c.Sinks {
+		field := "sinks[" + strconv.Itoa(i) + "]"
+		if sc.Name != "" {
+			if _, ok := names[sc.Name]; ok {
+				invalid(field+".name", sc.Name, "duplicate sink name")
+			}
+			names[sc.Name] = struct{}{}
+		}
+		switch typ := strings.ToLower(sc.Type); typ {
+		case "", SinkTypeStdout, SinkTypeStderr:
+		case SinkTypeFile, SinkTypeRotate:
+			errs = append(errs, sc.File.validate(field+".file")...)
+		case SinkTypeCustom:
+			if sc.Sink != "" {
+				if _, ok := lookupSink(sc.Sink); !ok {
+					invalid(field+".sink", sc.Sink, "sink not registered")
+				}
+			}
+		default:
+			invalid(field+".type", sc.Type, "must be one of stdout,stderr,file,rotate,custom")
+		}
+		if !validSinkLevel(sc.Level) {
+			invalid(field+".level", sc.Level, "unrecognized level")
+		}
+		if !validFormat(sc.Format) {
+			invalid(field+".format", sc.Format, "must be one of json,console")
+		}
+		if !validEncodeLevel(sc.EncodeLevel) {
+			invalid(field+".encodeLevel", sc.EncodeLevel, "unrecognized level encoder")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate validates the file config.
+func (cf *LumberjackFile) validate(field string) []error {
+	var errs []error
+	invalid := func(name string, value any, reason string) {
+		errs = append(errs, &ConfigError{Field: field + "." + name, Value: value, Reason: reason})
+	}
+	if cf.MaxSize < 0 {
+		invalid("maxSize", cf.MaxSize, "must not be negative")
+	}
+	if cf.MaxAge < 0 {
+		invalid("maxAge", cf.MaxAge, "must not be negative")
+	}
+	if cf.MaxBackups < 0 {
+		invalid("maxBackups", cf.MaxBackups, "must not be negative")
+	}
+	if cf.MaxTotalSize < 0 {
+		invalid("maxTotalSize", cf.MaxTotalSize, "must not be negative")
+	}
+	if cf.MinFreeSpace < 0 {
+		invalid("minFreeSpace", cf.MinFreeSpace, "must not be negative")
+	}
+	switch cf.LowSpacePolicy {
+	case "", LowSpaceDrop, LowSpaceStop:
+	default:
+		invalid("lowSpacePolicy", cf.LowSpacePolicy, "must be one of drop,stop")
+	}
+	if _, err := rotate.ParseCodec(cf.Compression); err != nil {
+		invalid("compression", cf.Compression, err.Error())
+	}
+	if _, err := rotate.ParseInterval(cf.Rotation); err != nil {
+		invalid("rotation", cf.Rotation, "must be one of hourly,daily or a duration")
+	}
+	if cf.Pattern != "" {
+		if err := rotate.ValidatePattern(filepath.Join(cf.Path, cf.Pattern)); err != nil {
+			invalid("pattern", cf.Pattern, err.Error())
+		}
+	}
+	if err := checkWritableDir(cf.Path); err != nil {
+		invalid("path", cf.Path, err.Error())
+	}
+	return errs
+}
+
+// checkWritableDir checks the directory is writable,
+// if the directory does not exist, checks the nearest existing parent directory.
+func checkWritableDir(dir string) error {
+	if dir == "" {
+		dir = "."
+	}
+	for {
+		fi, err := os.Stat(dir)
+		if err == nil {
+			if !fi.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return err
+		}
+		dir = parent
+	}
+	f, err := os.CreateTemp(dir, ".logger-*")
+	if err != nil {
+		return fmt.Errorf("directory %s not writable", dir)
+	}
+	name := f.Name()
+	_ = f.Close()
+	_ = os.Remove(name)
+	return nil
+}
+
+func validFormat(format string) bool {
+	return format == "" || format == FormatJson || format == FormatConsole
+}
+
+func validEncodeLevel(encodeLevel string) bool {
+	switch encodeLevel {
+	case "", EncodeLevelLowercase, EncodeLevelLowercaseColor, EncodeLevelCapital, EncodeLevelCapitalColor:
+		return true
+	default:
+		return false
+	}
+}
+
+// validSinkLevel empty sink level means no filter, also used for the optional level.
+func validSinkLevel(level string) bool {
+	if level == "" {
+		return true
+	}
+	_, err := zapcore.ParseLevel(level)
+	return err == nil
+}
@@ -16,11 +16,12 @@ func (l *Log) OnLevel(level Level) *Event {
 	return e
 }
 
-// OnLevelContext starts a new message with customize level, and adds the Go Context to the *Event context.
+// OnLevelContext starts a new message with customize level, and adds the Go Context to the *Event context,
+// the fields carried by the Go Context are appended to the *Event, see WithFields.
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnLevelContext(ctx context.Context, level Level) *Event {
-	return l.OnLevel(level).WithContext(ctx)
+	return l.OnLevel(level).WithContext(ctx).With(FieldsFromContext(ctx)...)
 }
 
 // OnDebug starts a new message with [DebugLevel] level.
@@ -34,7 +35,7 @@ func (l *Log) OnDebug() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnDebugContext(ctx context.Context) *Event {
-	return l.OnLevel(DebugLevel).WithContext(ctx)
+	return l.OnLevelContext(ctx, DebugLevel)
 }
 
 // OnInfo starts a new message with [InfoLevel] level.
@@ -48,7 +49,7 @@ func (l *Log) OnInfo() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnInfoContext(ctx context.Context) *Event {
-	return l.OnLevel(InfoLevel).WithContext(ctx)
+	return l.OnLevelContext(ctx, InfoLevel)
 }
 
 // OnWarn starts a new message with [WarnLevel] level.
@@ -62,7 +63,7 @@ func (l *Log) OnWarn() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnWarnContext(ctx context.Context) *Event {
-	return l.OnLevel(WarnLevel).WithContext(ctx)
+	return l.OnLevelContext(ctx, WarnLevel)
 }
 
 // OnError starts a new message with [ErrorLevel] level.
@@ -76,7 +77,7 @@ func (l *Log) OnError() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnErrorContext(ctx context.Context) *Event {
-	return l.OnLevel(ErrorLevel).WithContext(ctx)
+	return l.OnLevelContext(ctx, ErrorLevel)
 }
 
 // OnDPanic starts a new message with [DPanicLevel] level.
@@ -90,7 +91,7 @@ func (l *Log) OnDPanic() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnDPanicContext(ctx context.Context) *Event {
-	return l.OnLevel(DPanicLevel).WithContext(ctx)
+	return l.OnLevelContext(ctx, DPanicLevel)
 }
 
 // OnPanic starts a new message with [PanicLevel] level.
@@ -104,7 +105,7 @@ func (l *Log) OnPanic() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnPanicContext(ctx context.Context) *Event {
-	return l.OnLevel(PanicLevel).WithContext(ctx)
+	return l.OnLevelContext(ctx, PanicLevel)
 }
 
 // OnFatal starts a new message with [FatalLevel] level.
@@ -118,7 +119,7 @@ func (l *Log) OnFatal() *Event {
 //
 // You must call Msg on the returned event in order to send the event.
 func (l *Log) OnFatalContext(ctx context.Context) *Event {
-	return l.OnLevel(FatalLevel).WithContext(ctx)
+	return l.OnLevelContext(ctx, FatalLevel)
 }
 
 func (l *Log) Debug(args ...any) {
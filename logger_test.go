@@ -1,10 +1,29 @@
 package logger_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/thinkgos/logger"
+	"github.com/thinkgos/logger/logtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 )
 
 func init() {
@@ -99,6 +118,348 @@ func Test_Logger_Caller(t *testing.T) {
 	log.OnDebug().Msg("debug")
 }
 
+func Test_Logger_SinkLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithLevel(logger.DebugLevel.String()),
+		logger.WithAdapter(logger.AdapterCustom, buf),
+		logger.WithAdapterLevel(logger.AdapterCustom, logger.WarnLevel.String()),
+		logger.WithAdapterFormat(logger.AdapterCustom, logger.FormatConsole),
+	)
+	log.OnDebug().Msg("sink debug")
+	log.OnWarn().Msg("sink warn")
+	if got := buf.String(); strings.Contains(got, "sink debug") || !strings.Contains(got, "sink warn") {
+		t.Fatalf("unexpected sink output: %q", got)
+	}
+
+	lv, ok := log.SinkLevel(logger.SinkCustom)
+	if !ok {
+		t.Fatal("custom sink level should exist")
+	}
+	lv.SetLevel(logger.DebugLevel)
+	log.OnDebug().Msg("sink debug")
+	if got := buf.String(); !strings.Contains(got, "sink debug") {
+		t.Fatalf("sink level should be changed at runtime: %q", got)
+	}
+	if _, ok = log.SinkLevel(logger.SinkFile); ok {
+		t.Fatal("file sink level should not exist")
+	}
+}
+
+func Test_Logger_Sinks(t *testing.T) {
+	dir := t.TempDir()
+	log := logger.NewLogger(
+		logger.WithLevel(logger.DebugLevel.String()),
+		logger.WithSinks(
+			logger.SinkConfig{
+				Type:  logger.SinkTypeFile,
+				Level: logger.WarnLevel.String(),
+				File:  logger.LumberjackFile{Path: dir, Filename: "warn.log"},
+			},
+			logger.SinkConfig{
+				Type:   logger.SinkTypeFile,
+				Format: logger.FormatConsole,
+				File:   logger.LumberjackFile{Path: dir, Filename: "all.log"},
+			},
+		),
+	)
+	log.OnInfo().Msg("sinks info")
+	log.OnError().Msg("sinks error")
+	_ = log.Sync()
+
+	warn, err := os.ReadFile(filepath.Join(dir, "warn.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(warn), "sinks info") || !strings.Contains(string(warn), "sinks error") {
+		t.Fatalf("unexpected warn sink output: %q", warn)
+	}
+	all, err := os.ReadFile(filepath.Join(dir, "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(all), "sinks info") || !strings.Contains(string(all), "sinks error") {
+		t.Fatalf("unexpected all sink output: %q", all)
+	}
+	levels := log.SinkLevels()
+	if _, ok := levels[logger.SinkTypeFile]; !ok {
+		t.Fatalf("sink file should exist: %v", levels)
+	}
+	if _, ok := levels[logger.SinkTypeFile+"#1"]; !ok {
+		t.Fatalf("duplicate sink name should be suffixed: %v", levels)
+	}
+}
+
+func Test_Logger_MinFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithLevel(logger.DebugLevel.String()),
+		logger.WithAdapter(logger.AdapterCustom, buf), // the writer of the custom sink
+		logger.WithSinks(
+			logger.SinkConfig{
+				Type: logger.SinkTypeRotate,
+				File: logger.LumberjackFile{
+					Path:         dir,
+					Pattern:      "logs/app-%Y%m%d.log",
+					MinFreeSpace: 1 << 30, // 1PB, always under the threshold
+				},
+			},
+			logger.SinkConfig{Type: logger.SinkTypeCustom},
+		),
+	)
+	log.OnInfo().Msg("low space info")
+	log.OnWarn().Msg("low space warn")
+	log.OnError().Msg("low space error")
+	_ = log.Close(context.Background())
+
+	matches, err := filepath.Glob(filepath.Join(dir, "logs", "app-*.log"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("unexpected files: %v, %v", matches, err)
+	}
+	b, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	if strings.Contains(got, "low space info") || strings.Contains(got, "low space warn") || !strings.Contains(got, "low space error") {
+		t.Fatalf("entries below error level should be dropped: %q", got)
+	}
+	if strings.Contains(got, "free space of") {
+		t.Fatalf("the warning should not be written to the full sink: %q", got)
+	}
+	if others := buf.String(); strings.Count(others, "free space of "+filepath.Join(dir, "logs")) != 1 {
+		t.Fatalf("should warn only once through the other sinks: %q", others)
+	}
+}
+
+// sinkSeq makes the registered sink names unique, as the registry is process-global and the test may run with -count.
+var sinkSeq atomic.Int64
+
+func Test_Logger_RegisterSink(t *testing.T) {
+	name := fmt.Sprintf("test-buffer-%d", sinkSeq.Add(1))
+	buf := &bytes.Buffer{}
+	err := logger.RegisterSink(name, func(params map[string]any) (zapcore.WriteSyncer, error) {
+		if params["prefix"] != "x" {
+			return nil, fmt.Errorf("unexpected params: %v", params)
+		}
+		return zapcore.AddSync(buf), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = logger.RegisterSink(name, func(map[string]any) (zapcore.WriteSyncer, error) { return nil, nil }); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("register the same name should failed, got %v", err)
+	}
+	if err = logger.RegisterSink(name+"-nil", nil); err == nil || !strings.Contains(err.Error(), "must not be nil") {
+		t.Fatalf("register nil factory should failed, got %v", err)
+	}
+
+	var c logger.Config
+	err = json.Unmarshal([]byte(`{"level":"info","sinks":[{"type":"custom","sink":"`+name+`","params":{"prefix":"x"}}]}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logger.NewLogger(logger.WithConfig(c))
+	log.OnInfo().Msg("registered sink")
+	if !strings.Contains(buf.String(), "registered sink") {
+		t.Fatalf("unexpected registered sink output: %q", buf.String())
+	}
+
+	// the std files returned by the factory are not closed.
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	old := os.Stdout
+	os.Stdout = stdout
+	defer func() { os.Stdout = old }()
+	stdName := fmt.Sprintf("test-stdout-%d", sinkSeq.Add(1))
+	if err = logger.RegisterSink(stdName, func(map[string]any) (zapcore.WriteSyncer, error) { return os.Stdout, nil }); err != nil {
+		t.Fatal(err)
+	}
+	log = logger.NewLogger(logger.WithSinks(logger.SinkConfig{Type: logger.SinkTypeCustom, Sink: stdName}))
+	if err = log.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stdout.WriteString("still open"); err != nil {
+		t.Errorf("os.Stdout should not be closed: %v", err)
+	}
+}
+
+func Test_Logger_NewE(t *testing.T) {
+	_, err := logger.NewE(
+		logger.WithLevel("verbose"),
+		logger.WithFormat("xml"),
+		logger.WithEncodeLevel("NoSuchEncoder"),
+		logger.WithAdapter("syslog"),
+		logger.WithMaxSize(-1),
+		logger.WithSinks(logger.SinkConfig{Type: "kafka", Level: "loud"}),
+	)
+	if err == nil {
+		t.Fatal("invalid config should failed")
+	}
+	for _, field := range []string{"level=", "format=", "encodeLevel=", "sinks[0].type=", "sinks[0].level="} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("error should report %q: %v", field, err)
+		}
+	}
+	var ce *logger.ConfigError
+	if !errors.As(err, &ce) {
+		t.Errorf("error should be ConfigError: %v", err)
+	}
+
+	_, err = logger.NewE(logger.WithAdapter(logger.AdapterFile), logger.WithMaxSize(-1), logger.WithPath(t.TempDir()))
+	if err == nil || !strings.Contains(err.Error(), "file.maxSize=") {
+		t.Fatalf("negative max size should failed: %v", err)
+	}
+
+	log, err := logger.NewE(logger.WithLevel(logger.InfoLevel.String()), logger.WithAdapter(logger.AdapterCustom, io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.OnInfo().Msg("NewE")
+}
+
+func Test_Logger_Watcher(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithLevel(logger.InfoLevel.String()),
+		logger.WithAdapter(logger.AdapterCustom, buf),
+	)
+	child := log.With(logger.String("child", "yes"))
+
+	path := filepath.Join(t.TempDir(), "logger.yaml")
+	err := os.WriteFile(path, []byte("level: debug\nformat: console\nadapter: custom\ncallerLevel: debug\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := logger.NewWatcher(log, path,
+		logger.WithWatchInterval(time.Hour),
+		logger.WithWatchErrorHandler(func(err error) { t.Log(err) }),
+	)
+	if err = w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if got := log.GetLevel(); got != logger.DebugLevel {
+		t.Fatalf("level should be reloaded, got %v", got)
+	}
+	if got := log.UnderlyingCallerLevel().Level(); got != logger.DebugLevel {
+		t.Fatalf("caller level should be reloaded, got %v", got)
+	}
+	child.OnDebug().Msg("reloaded")
+	if got := buf.String(); !strings.Contains(got, "reloaded") || !strings.Contains(got, `{"child": "yes"`) {
+		t.Fatalf("unexpected reloaded output: %q", got)
+	}
+
+	err = os.WriteFile(path, []byte("level: verbose\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Reload(); err == nil {
+		t.Fatal("invalid config should failed")
+	}
+	if got := log.GetLevel(); got != logger.DebugLevel {
+		t.Fatalf("level should be kept, got %v", got)
+	}
+
+	err = os.WriteFile(path, []byte("level: debug\nformat: console\nadapter: custom\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.UnderlyingCallerLevel().Level(); got != logger.ErrorLevel {
+		t.Fatalf("caller level should be reset to the default, got %v", got)
+	}
+}
+
+func Test_Logger_NameLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithLevel(logger.WarnLevel.String()),
+		logger.WithAdapter(logger.AdapterCustom, buf),
+		logger.WithNameLevel("db.*", logger.DebugLevel.String()),
+	)
+	db := log.Named("db").Named("conn")
+	if !db.Enabled(logger.DebugLevel) || log.Enabled(logger.DebugLevel) || log.Named("db").Enabled(logger.DebugLevel) {
+		t.Fatal("name level override should only work on db.*")
+	}
+	db.OnDebug().Msg("db debug")
+	db.Logger().Debug("db zap debug")
+	log.Named("http").Logger().Debug("http zap debug")
+	if got := buf.String(); !strings.Contains(got, "db debug") || !strings.Contains(got, "db zap debug") || strings.Contains(got, "http zap debug") {
+		t.Fatalf("unexpected name level output: %q", got)
+	}
+
+	if err := log.SetNameLevel("http", logger.InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	if !log.Named("http").Enabled(logger.InfoLevel) {
+		t.Fatal("name level should be changed at runtime")
+	}
+	log.DeleteNameLevel("http")
+	if log.Named("http").Enabled(logger.InfoLevel) {
+		t.Fatal("name level should be deleted at runtime")
+	}
+	if err := log.SetNameLevel("*", logger.InfoLevel); err == nil {
+		t.Fatal("invalid pattern should failed")
+	}
+
+	other := logger.NewLogger(logger.WithAdapter(logger.AdapterCustom, io.Discard)).Named("other")
+	if n := testing.AllocsPerRun(100, func() { other.Enabled(logger.DebugLevel) }); n != 0 {
+		t.Fatalf("Enabled without override should not allocate, got %v", n)
+	}
+}
+
+func Test_Logger_AdminHandler(t *testing.T) {
+	log := logger.NewLogger(
+		logger.WithLevel(logger.WarnLevel.String()),
+		logger.WithAdapter(logger.AdapterCustom, io.Discard),
+	)
+	h := log.AdminHandler()
+	do := func(method, target, contentType, body string) (int, string) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, body := do(http.MethodGet, "/level", "", ""); code != http.StatusOK || !strings.Contains(body, `"warn"`) {
+		t.Fatalf("unexpected level: %d %s", code, body)
+	}
+	if code, body := do(http.MethodPut, "/level", "application/json", `{"level":"debug"}`); code != http.StatusOK || log.GetLevel() != logger.DebugLevel {
+		t.Fatalf("unexpected put level: %d %s", code, body)
+	}
+	if code, body := do(http.MethodPut, "/caller", "application/x-www-form-urlencoded", "level=info"); code != http.StatusOK || log.UnderlyingCallerLevel().Level() != logger.InfoLevel {
+		t.Fatalf("unexpected put caller level: %d %s", code, body)
+	}
+	if code, body := do(http.MethodPut, "/sinks/custom", "application/json", `{"level":"error"}`); code != http.StatusOK || !strings.Contains(body, `"error"`) {
+		t.Fatalf("unexpected put sink level: %d %s", code, body)
+	}
+	if code, _ := do(http.MethodGet, "/sinks/nope", "", ""); code != http.StatusNotFound {
+		t.Fatalf("unknown sink should not found: %d", code)
+	}
+	if code, _ := do(http.MethodPut, "/level", "application/json", `{"level":"verbose"}`); code != http.StatusBadRequest {
+		t.Fatalf("invalid level should be bad request: %d", code)
+	}
+	if code, body := do(http.MethodPut, "/names/db.*", "application/json", `{"level":"debug"}`); code != http.StatusOK || !log.Named("db").Named("conn").Enabled(logger.DebugLevel) {
+		t.Fatalf("unexpected put name level: %d %s", code, body)
+	}
+	if code, _ := do(http.MethodDelete, "/names/db.*", "", ""); code != http.StatusNoContent || len(log.NameLevels()) != 0 {
+		t.Fatalf("unexpected delete name level: %d", code)
+	}
+	if code, body := do(http.MethodGet, "/", "", ""); code != http.StatusOK || !strings.Contains(body, `"callerLevel":"info"`) {
+		t.Fatalf("unexpected levels: %d %s", code, body)
+	}
+}
+
 func shouldPanic(t *testing.T, f func()) {
 	defer func() {
 		e := recover()
@@ -108,3 +469,686 @@ func shouldPanic(t *testing.T, f func()) {
 	}()
 	f()
 }
+
+// blockWriter blocks the write until the gate closed.
+type blockWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *blockWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func Test_Logger_Async(t *testing.T) {
+	t.Run("sync drains", func(t *testing.T) {
+		w := &blockWriter{gate: make(chan struct{})}
+		close(w.gate)
+		log := logger.NewLogger(
+			logger.WithLevel("info"),
+			logger.WithAdapter(logger.AdapterCustom, w),
+			logger.WithAsync(logger.AsyncConfig{Enable: true, Size: 16, FlushInterval: "1h"}),
+		)
+		log.OnInfo().Msg("async message")
+		_ = log.Sync()
+		if !strings.Contains(w.String(), "async message") {
+			t.Fatalf("sync should drain the buffer: %q", w.String())
+		}
+	})
+	t.Run("drop newest", func(t *testing.T) {
+		w := &blockWriter{gate: make(chan struct{})}
+		log := logger.NewLogger(
+			logger.WithLevel("info"),
+			logger.WithAdapter(logger.AdapterCustom, w),
+			logger.WithAsync(logger.AsyncConfig{Enable: true, Size: 2, FlushInterval: "1h", Overflow: logger.OverflowDropNewest}),
+		)
+		for i := 0; i < 10; i++ {
+			log.OnInfo().Printf("message %d", i)
+		}
+		close(w.gate)
+		_ = log.Sync()
+		dropped := log.AsyncDropped()[logger.SinkCustom]
+		if dropped == 0 {
+			t.Fatal("entries should be dropped when the buffer is full")
+		}
+		if got := strings.Count(w.String(), "message"); uint64(got)+dropped != 10 {
+			t.Fatalf("written %d + dropped %d should be 10", got, dropped)
+		}
+
+		err := log.Reload(logger.Config{
+			Level:   "info",
+			Adapter: logger.AdapterCustom,
+			Writer:  []io.Writer{w},
+			Async:   logger.AsyncConfig{Enable: true, Size: 2, FlushInterval: "1h", Overflow: logger.OverflowDropNewest},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := log.AsyncDropped()[logger.SinkCustom]; got != dropped {
+			t.Fatalf("dropped counter should be kept across reload, got %d, want %d", got, dropped)
+		}
+	})
+}
+
+func Test_Logger_Close(t *testing.T) {
+	dir := t.TempDir()
+	log := logger.NewLogger(
+		logger.WithLevel("info"),
+		logger.WithSinks(logger.SinkConfig{Type: "file", File: logger.LumberjackFile{Path: dir, Filename: "close.log"}}),
+		logger.WithAsync(logger.AsyncConfig{Enable: true, FlushInterval: "1h"}),
+	)
+	log.OnInfo().Msg("before close")
+	if err := log.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	log.OnInfo().Msg("after close")
+	log.With(logger.String("k", "v")).OnError().Msg("after close")
+	if err := log.Close(context.Background()); err != nil {
+		t.Fatalf("close twice should be ok: %v", err)
+	}
+	if err := log.Reload(logger.Config{Level: "info"}); err == nil {
+		t.Fatal("reload after close should failed")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "close.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "before close") || strings.Contains(string(b), "after close") {
+		t.Fatalf("unexpected output: %q", b)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := &blockWriter{gate: make(chan struct{})}
+	defer close(w.gate)
+	log = logger.NewLogger(
+		logger.WithLevel("info"),
+		logger.WithAdapter(logger.AdapterCustom, w),
+		logger.WithAsync(logger.AsyncConfig{Enable: true, FlushInterval: "1h"}),
+	)
+	log.OnInfo().Msg("blocked")
+	if err := log.Close(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("close should honor the context: %v", err)
+	}
+}
+
+func Test_Logger_Sampling(t *testing.T) {
+	t.Run("sampler", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(
+			logger.WithLevel("info"),
+			logger.WithAdapter(logger.AdapterCustom, buf),
+			logger.WithSampling(logger.SamplingConfig{Initial: 2, Thereafter: 3, Tick: "1h"}),
+		)
+		for i := 0; i < 8; i++ {
+			log.OnWarn().Msg("flood")
+		}
+		// 1,2 initial, then 5,8
+		if got := strings.Count(buf.String(), "flood"); got != 4 {
+			t.Fatalf("sampled count = %d, want 4", got)
+		}
+	})
+	t.Run("every", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf))
+		emit := func() { log.OnWarn().Every(50 * time.Millisecond).Msg("every") }
+		for i := 0; i < 5; i++ {
+			emit()
+		}
+		if got := strings.Count(buf.String(), "every"); got != 1 {
+			t.Fatalf("every count = %d, want 1", got)
+		}
+		time.Sleep(60 * time.Millisecond)
+		emit()
+		if !strings.Contains(buf.String(), `"suppressed":4`) {
+			t.Fatalf("should report suppressed count: %q", buf.String())
+		}
+	})
+	t.Run("every per logger", func(t *testing.T) {
+		buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+		log1 := logger.NewLoggerWith(zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(buf1), zap.InfoLevel)), zap.NewAtomicLevel())
+		log2 := logger.NewLoggerWith(zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(buf2), zap.InfoLevel)), zap.NewAtomicLevel())
+		emit := func(log *logger.Log) { log.OnWarn().Every(time.Hour).Msg("every") }
+		emit(log1)
+		emit(log2)
+		if !strings.Contains(buf1.String(), "every") || !strings.Contains(buf2.String(), "every") {
+			t.Fatalf("the loggers should not share the limiter: %q, %q", buf1.String(), buf2.String())
+		}
+		if n := testing.AllocsPerRun(100, func() { emit(log1) }); n != 0 {
+			t.Fatalf("suppressed event should not allocate, got %v", n)
+		}
+	})
+	t.Run("sampled", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := logger.NewLogger(
+			logger.WithLevel("info"),
+			logger.WithAdapter(logger.AdapterCustom, buf),
+			logger.WithSampling(logger.SamplingConfig{Rate: 0.001, Burst: 2}),
+		)
+		for i := 0; i < 5; i++ {
+			log.OnWarn().Sampled("a").Msg("sampled a")
+			log.OnWarn().Sampled("b").Msg("sampled b")
+		}
+		if a, b := strings.Count(buf.String(), "sampled a"), strings.Count(buf.String(), "sampled b"); a != 2 || b != 2 {
+			t.Fatalf("sampled count = %d,%d, want 2,2", a, b)
+		}
+		if e := log.OnWarn().Sampled("a"); e != nil {
+			t.Fatal("suppressed event should be nil")
+		}
+	})
+}
+
+func Test_Logger_Dedup(t *testing.T) {
+	newLog := func(window string) (*logger.Log, *blockWriter) {
+		w := &blockWriter{gate: make(chan struct{})}
+		close(w.gate)
+		return logger.NewLogger(
+			logger.WithLevel("info"),
+			logger.WithAdapter(logger.AdapterCustom, w),
+			logger.WithDedup(logger.DedupConfig{Enable: true, Window: window}),
+		), w
+	}
+	messages := func(out string) []string {
+		var msgs []string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			var m map[string]any
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				t.Fatal(err)
+			}
+			msg := m["msg"].(string)
+			if n, ok := m["repeated"]; ok {
+				msg += fmt.Sprintf("x%v", n)
+			}
+			msgs = append(msgs, msg)
+		}
+		return msgs
+	}
+
+	t.Run("consecutive", func(t *testing.T) {
+		log, w := newLog("1h")
+		for i := 0; i < 5; i++ {
+			log.OnWarn().With(logger.String("k", "v")).Msg("dup")
+		}
+		log.OnWarn().Msg("other")
+		if got, want := messages(w.String()), []string{"dup", "dupx4", "other"}; !slices.Equal(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for _, want := range []string{`"first":`, `"last":`} {
+			if !strings.Contains(w.String(), want) {
+				t.Fatalf("summary should contain %s: %q", want, w.String())
+			}
+		}
+	})
+	t.Run("dpanic", func(t *testing.T) {
+		log, w := newLog("1h")
+		for i := 0; i < 3; i++ {
+			log.OnWarn().Msg("dup")
+		}
+		log.Logger().DPanic("crash")
+		if got, want := messages(w.String()), []string{"dup", "dupx2", "crash"}; !slices.Equal(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("interleaved", func(t *testing.T) {
+		log, w := newLog("1h")
+		for i := 0; i < 2; i++ {
+			log.OnWarn().Msg("a")
+			log.OnWarn().Msg("b")
+		}
+		_ = log.Sync()
+		if got, want := messages(w.String()), []string{"a", "b", "a", "b"}; !slices.Equal(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("window expired", func(t *testing.T) {
+		log, w := newLog("20ms")
+		for i := 0; i < 3; i++ {
+			log.OnWarn().Msg("burst")
+		}
+		deadline := time.Now().Add(time.Second)
+		for !strings.Contains(w.String(), `"repeated":2`) {
+			if time.Now().After(deadline) {
+				t.Fatalf("summary should be written when the window expired: %q", w.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+}
+
+func Test_Logger_Redact(t *testing.T) {
+	type request struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+		Nested   struct {
+			AccessToken string `json:"access_token"`
+		} `json:"nested"`
+	}
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(
+		logger.WithLevel("info"),
+		logger.WithAdapter(logger.AdapterCustom, buf),
+		logger.WithRedact(logger.RedactConfig{
+			Keys:     []string{"password"},
+			Patterns: []string{"*token*"},
+			Values:   []string{logger.RedactBearer, logger.RedactEmail},
+		}),
+	)
+	req := request{User: "alice", Password: "p@ss"}
+	req.Nested.AccessToken = "tok-123"
+	log.With(logger.String("password", "ctx-secret")).OnInfo().
+		Any("req", req).
+		Dict("dict", logger.String("refresh_token", "tok-456"), logger.String("ok", "visible")).
+		String("auth", "Bearer abc.def").
+		String("mail", "contact alice@example.com now").
+		Array("byte_strings", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			enc.AppendByteString([]byte("Bearer bytes.secret"))
+			return nil
+		})).
+		Any("plain", struct{ Count int }{Count: 1}).
+		Msg("redact")
+	slog.New(logger.NewSlogHandler(log)).WithGroup("http").Info("slog", "password", "slog-secret")
+
+	out := buf.String()
+	for _, leaked := range []string{"p@ss", "tok-123", "tok-456", "abc.def", "alice@example.com", "ctx-secret", "slog-secret", "bytes.secret"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("%q should be redacted: %s", leaked, out)
+		}
+	}
+	for _, want := range []string{`"user":"alice"`, `"ok":"visible"`, "contact ****** now", `"plain":{"Count":1}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q should be kept: %s", want, out)
+		}
+	}
+
+	buf.Reset()
+	log = logger.NewLogger(
+		logger.WithLevel("info"),
+		logger.WithAdapter(logger.AdapterCustom, buf),
+		logger.WithRedact(logger.RedactConfig{Keys: []string{"password"}, Action: logger.RedactHash}),
+	)
+	log.OnInfo().String("password", "p@ss").Msg("hash")
+	if strings.Contains(buf.String(), "p@ss") || !strings.Contains(buf.String(), `"password":"sha256:`) {
+		t.Errorf("password should be hashed: %s", buf.String())
+	}
+
+	// the mask only applies to the struct fields tagged with redact, the other fields are encoded as is.
+	plain := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, io.Discard))
+	masked := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, io.Discard), logger.WithRedact(logger.RedactConfig{Mask: "[hidden]"}))
+	want := testing.AllocsPerRun(100, func() { plain.OnInfo().String("k", "v").Msg("plain") })
+	if got := testing.AllocsPerRun(100, func() { masked.OnInfo().String("k", "v").Msg("plain") }); got > want {
+		t.Errorf("mask only config allocates %v per entry, want %v", got, want)
+	}
+
+	buf.Reset()
+	log = logger.NewLogger(
+		logger.WithLevel("info"),
+		logger.WithAdapter(logger.AdapterCustom, buf),
+		logger.WithRedact(logger.RedactConfig{Values: []string{logger.RedactCreditCard}}),
+	)
+	log.OnInfo().String("card", "paid by 4111 1111 1111 1111").String("ts", "1700000000123456789").Msg("card")
+	if strings.Contains(buf.String(), "4111 1111") || !strings.Contains(buf.String(), `"card":"paid by ******"`) {
+		t.Errorf("card number should be redacted: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"ts":"1700000000123456789"`) {
+		t.Errorf("digits failed the luhn check should be kept: %s", buf.String())
+	}
+
+	// the error of the nested object is kept.
+	buf.Reset()
+	log = logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf), logger.WithRedact(logger.RedactConfig{Keys: []string{"password"}}))
+	log.OnInfo().Object("outer", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		return enc.AddObject("inner", zapcore.ObjectMarshalerFunc(func(zapcore.ObjectEncoder) error { return errors.New("boom") }))
+	})).Msg("nested error")
+	if !strings.Contains(buf.String(), `"outerError":"boom"`) {
+		t.Errorf("nested error should be reported: %s", buf.String())
+	}
+
+	// keys only config does not stringify the values.
+	keysOnly := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, io.Discard), logger.WithRedact(logger.RedactConfig{Keys: []string{"password"}}))
+	var calls atomic.Int64
+	keysOnly.OnInfo().Stringer("s", countingStringer{&calls}).Msg("stringer")
+	if n := calls.Load(); n != 1 {
+		t.Errorf("String() called %d times, want 1", n)
+	}
+}
+
+// countingStringer counts the String calls.
+type countingStringer struct {
+	calls *atomic.Int64
+}
+
+func (s countingStringer) String() string {
+	s.calls.Add(1)
+	return "stringer"
+}
+
+func Test_Logger_Struct(t *testing.T) {
+	type Base struct {
+		ID int `log:"id"`
+	}
+	type address struct {
+		City string `log:"city"`
+	}
+	type user struct {
+		Base
+		Name     string            `log:"name"`
+		Password string            `log:"password,redact"`
+		Nick     string            `log:"nick,omitempty"`
+		Internal string            `log:"-"`
+		Timeout  time.Duration     `log:"timeout"`
+		Tags     []string          `log:"tags"`
+		Addr     *address          `log:"addr"`
+		Extra    map[string]int    `log:"extra"`
+		Empty    *address          `log:"empty"`
+		Labels   map[string]string `log:"labels,omitempty"`
+		secret   string
+	}
+	u := user{
+		Base:     Base{ID: 7},
+		Name:     "alice",
+		Password: "p@ss",
+		Internal: "internal",
+		Timeout:  time.Second,
+		Tags:     []string{"a", "b"},
+		Addr:     &address{City: "sh"},
+		Extra:    map[string]int{"y": 2, "x": 1},
+		secret:   "secret",
+	}
+
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf))
+	log.OnInfo().Struct("user", &u).Msg("struct")
+	want := `"user":{"id":7,"name":"alice","password":"******","timeout":"1s","tags":["a","b"],"addr":{"city":"sh"},"extra":{"x":1,"y":2},"empty":null}`
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("unexpected struct output:\n got: %s\nwant: %s", buf.String(), want)
+	}
+	if m := logger.StructMarshaler(1); m != nil {
+		t.Fatal("non struct should return nil marshaler")
+	}
+
+	type promoted struct {
+		address
+		Name string `log:"name"`
+	}
+	type node struct {
+		Name string `log:"name"`
+		Next *node  `log:"next"`
+	}
+	cyclic := &node{Name: "a"}
+	cyclic.Next = cyclic
+	buf.Reset()
+	log.OnInfo().Struct("promoted", promoted{address{City: "sh"}, "bob"}).Struct("node", cyclic).Msg("struct")
+	for _, want := range []string{`"promoted":{"city":"sh","name":"bob"}`, `"nodeError":"logger: exceeded the max depth`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("unexpected struct output:\n got: %s\nwant: %s", buf.String(), want)
+		}
+	}
+
+	type holder struct {
+		Custom   ptrMarshaler `log:"custom"`
+		Password string       `log:"password,redact"`
+	}
+	for _, tt := range []struct {
+		redact logger.RedactConfig
+		want   string
+	}{
+		{logger.RedactConfig{Mask: "[hidden]"}, `"holder":{"custom":{"v":"x"},"password":"[hidden]"}`},
+		{logger.RedactConfig{Action: logger.RedactDrop}, `"holder":{"custom":{"v":"x"}}`},
+	} {
+		buf.Reset()
+		log = logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf), logger.WithRedact(tt.redact))
+		log.OnInfo().Struct("holder", holder{Custom: ptrMarshaler{v: "x"}, Password: "p@ss"}).Msg("struct")
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("unexpected struct output:\n got: %s\nwant: %s", buf.String(), tt.want)
+		}
+	}
+}
+
+// ptrMarshaler implements zapcore.ObjectMarshaler with pointer receiver.
+type ptrMarshaler struct {
+	v string
+}
+
+func (p *ptrMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("v", p.v)
+	return nil
+}
+
+func Test_Logger_TraceHook(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tc, err := logger.ParseTraceparent(traceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tc.Traceparent(); got != traceparent {
+		t.Fatalf("Traceparent() = %s, want %s", got, traceparent)
+	}
+	for _, invalid := range []string{"", "00-xyz-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"} {
+		if _, err = logger.ParseTraceparent(invalid); err == nil {
+			t.Errorf("ParseTraceparent(%q) should failed", invalid)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf)).
+		ExtendHook(logger.NewTraceHook())
+	ctx, err := logger.ContextWithTraceparent(context.Background(), traceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.OnInfoContext(ctx).Msg("traced")
+	log.OnInfo().Msg("untraced")
+	want := `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"`
+	if !strings.Contains(buf.String(), want) || strings.Count(buf.String(), "trace_id") != 1 {
+		t.Fatalf("unexpected trace output: %s", buf.String())
+	}
+
+	buf.Reset()
+	log = logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf)).
+		ExtendHook(logger.NewTraceHook(
+			logger.WithTraceKeys("traceId", "spanId", ""),
+			logger.WithTraceExtractor(logger.TraceExtractorFunc(func(context.Context) (logger.TraceContext, bool) {
+				return tc, true
+			})),
+		))
+	log.OnInfo().Msg("custom")
+	if !strings.Contains(buf.String(), `"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7"`) || strings.Contains(buf.String(), "flags") {
+		t.Fatalf("unexpected custom trace output: %s", buf.String())
+	}
+}
+
+func Test_Logger_ContextFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf))
+	if logger.FromContext(context.Background()) != logger.UnderlyingLogger() {
+		t.Fatal("FromContext should return the default logger if not present")
+	}
+
+	ctx := logger.NewContext(context.Background(), log)
+	ctx = logger.WithFields(ctx, logger.String("request_id", "r1"))
+	child := logger.WithFields(ctx, logger.String("user", "u1"))
+	if logger.FromContext(child) != log {
+		t.Fatal("FromContext should return the context logger")
+	}
+
+	logger.OnInfoContext(child).Msg("package level")
+	log.OnWarnContext(ctx).Msg("parent")
+	out := buf.String()
+	if !strings.Contains(out, `"msg":"package level","request_id":"r1","user":"u1"`) {
+		t.Fatalf("context fields should be appended: %s", out)
+	}
+	if strings.Count(out, `"user"`) != 1 || strings.Count(out, `"request_id"`) != 2 {
+		t.Fatalf("child fields should not affect the parent: %s", out)
+	}
+}
+
+func Test_Logger_Recover(t *testing.T) {
+	buf := &blockWriter{gate: make(chan struct{})}
+	close(buf.gate)
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithAdapter(logger.AdapterCustom, buf))
+	ctx := logger.WithFields(context.Background(), logger.String("job", "j1"))
+
+	func() {
+		defer log.Recover(ctx)
+		panic("boom")
+	}()
+	func() {
+		defer logger.Recover(logger.NewContext(ctx, log))
+		panic(errors.New("package boom"))
+	}()
+	func() {
+		defer func() {
+			if v := recover(); v != "again" {
+				t.Errorf("should re-panic, got %v", v)
+			}
+		}()
+		defer log.Recover(ctx, logger.WithRepanic())
+		panic("again")
+	}()
+
+	log.Go(func() { panic("worker") }, logger.WithRecoverContext(ctx))
+	for i := 0; i < 100 && !strings.Contains(buf.String(), "worker"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	out := buf.String()
+	for _, want := range []string{`"panic":"boom"`, `"panic":"package boom"`, `"panic":"again"`, `"panic":"worker"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %s: %s", want, out)
+		}
+	}
+	if strings.Count(out, `"job":"j1"`) != 4 || strings.Count(out, `"stack":"`) != 4 {
+		t.Errorf("each panic should log the context fields and the stack: %s", out)
+	}
+}
+
+func Test_Logger_TimeEncoding(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 6_000_000, time.UTC)
+	tests := []struct {
+		name string
+		opts []logger.Option
+		want string
+	}{
+		{"default", nil, `"ts":"2024-01-02T03:04:05Z"`},
+		{"rfc3339nano key", []logger.Option{logger.WithTimeFieldKey("time"), logger.WithTimeEncoding(logger.TimeEncodingRFC3339Nano)}, `"time":"2024-01-02T03:04:05.006Z"`},
+		{"epoch-millis", []logger.Option{logger.WithTimeEncoding(logger.TimeEncodingEpochMillis)}, `"ts":1704164645006`},
+		{"epoch-nanos", []logger.Option{logger.WithTimeEncoding(logger.TimeEncodingEpochNanos)}, `"ts":1704164645006000000`},
+		{"layout zone", []logger.Option{logger.WithTimeEncoding("2006-01-02 15:04:05"), logger.WithTimeZone("Asia/Shanghai")}, `"ts":"2024-01-02 11:04:05"`},
+		{"omit", []logger.Option{logger.WithTimeFieldKey("-")}, `{"level":"info","msg":"time"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			opts := append([]logger.Option{
+				logger.WithLevel("info"),
+				logger.WithAdapter(logger.AdapterCustom, buf),
+				logger.WithClock(logtest.NewClock(start, 0)),
+			}, tt.opts...)
+			logger.NewLogger(opts...).OnInfo().Msg("time")
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("got %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if _, err := logger.NewE(logger.WithTimeZone("Mars/Olympus")); err == nil || !strings.Contains(err.Error(), "timeZone") {
+		t.Errorf("invalid time zone should failed: %v", err)
+	}
+}
+
+func Test_Logger_EncoderSettings(t *testing.T) {
+	var c logger.Config
+	err := yaml.Unmarshal([]byte(`
+level: info
+adapter: custom
+timeKey: "-"
+encoder:
+  messageKey: message
+  levelKey: severity
+  nameKey: name
+  callerKey: src
+  lineEnding: "\r\n"
+  callerEncoding: package
+  durationEncoding: millis
+  nameEncoding: short
+`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	log := logger.NewLogger(logger.WithConfig(c), logger.WithAdapter(logger.AdapterCustom, buf))
+	log.Named("db").Named("query").OnInfo().Duration("elapsed", 1500*time.Millisecond).Msg("done")
+
+	got := buf.String()
+	if !strings.HasSuffix(got, "\r\n") {
+		t.Errorf("unexpected line ending: %q", got)
+	}
+	m := map[string]any{}
+	if err = json.Unmarshal([]byte(got), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"message": "done", "severity": "info", "name": "query", "elapsed": float64(1500)}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s = %v, want %v, got %s", k, m[k], v, got)
+		}
+	}
+	if _, ok := m["ts"]; ok {
+		t.Errorf("time should be omitted, got %s", got)
+	}
+
+	buf.Reset()
+	log.Logger().WithOptions(zap.AddCaller()).Info("caller")
+	if got = buf.String(); !strings.Contains(got, `"src":"github.com/thinkgos/logger_test/logger_test.go:`) {
+		t.Errorf("caller should be package relative, got %s", got)
+	}
+
+	_, err = logger.NewE(logger.WithEncoderSettings(logger.EncoderSettings{CallerEncoding: "long", DurationEncoding: "hours"}))
+	if err == nil || !strings.Contains(err.Error(), "encoder.callerEncoding") || !strings.Contains(err.Error(), "encoder.durationEncoding") {
+		t.Errorf("invalid encoder settings should failed: %v", err)
+	}
+}
+
+func Test_Logger_ReloadInFlight(t *testing.T) {
+	dir := t.TempDir()
+	sink := func(name string) logger.SinkConfig {
+		return logger.SinkConfig{Type: logger.SinkTypeFile, File: logger.LumberjackFile{Path: dir, Filename: name}}
+	}
+	log := logger.NewLogger(logger.WithLevel("info"), logger.WithSinks(sink("old.log")))
+	ce := log.Logger().Check(logger.InfoLevel, "in flight")
+	if ce == nil {
+		t.Fatal("entry should be checked")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- log.Reload(logger.Config{Level: "info", Sinks: []logger.SinkConfig{sink("new.log")}}) }()
+	select {
+	case err := <-done:
+		t.Fatalf("reload should wait for the in-flight entry: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	ce.Write()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	log.OnInfo().Msg("after reload")
+
+	b, err := os.ReadFile(filepath.Join(dir, "old.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "in flight") || strings.Contains(string(b), "after reload") {
+		t.Fatalf("in-flight entry should be written to the old sink: %q", b)
+	}
+}
@@ -0,0 +1,172 @@
+package logtest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/thinkgos/logger"
+)
+
+// the flag is namespaced, so it does not conflict with the -update flag of the test package.
+var update = flag.Bool("logtest.update", false, "update the golden files of logtest.Golden, same as LOGTEST_UPDATE=1")
+
+// updateGolden reports whether to write the golden files, by the -logtest.update flag or the LOGTEST_UPDATE env.
+func updateGolden() bool {
+	if *update {
+		return true
+	}
+	ok, _ := strconv.ParseBool(os.Getenv("LOGTEST_UPDATE"))
+	return ok
+}
+
+// GoldenTime the start time of the clock of NewGoldenLogger.
+var GoldenTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+var _ zapcore.Clock = (*Clock)(nil)
+
+// Clock is a deterministic zapcore.Clock, which advances a step on each Now call.
+type Clock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewClock new clock starts at start, advances step on each Now call, step 0 means a fixed clock.
+func NewClock(start time.Time, step time.Duration) *Clock {
+	return &Clock{now: start, step: step}
+}
+
+// Now implements zapcore.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// NewTicker implements zapcore.Clock.
+func (c *Clock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
+
+// StableCaller is a caller function of logger.Log.SetCaller,
+// which emits the path relative to the working directory (the package directory in tests) and the line,
+// the frames of the logger package and zap are skipped.
+func StableCaller(depth int, skipPackages ...string) logger.Field {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(depth+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !skipFrame(frame.Function, skipPackages) {
+			return zap.String(CallerKey, relativePath(frame.File)+":"+strconv.Itoa(frame.Line))
+		}
+		if !more {
+			return zap.String(CallerKey, "???")
+		}
+	}
+}
+
+func skipFrame(function string, skipPackages []string) bool {
+	if strings.HasPrefix(function, "github.com/thinkgos/logger.") ||
+		strings.HasPrefix(function, "go.uber.org/zap") ||
+		strings.HasPrefix(function, "runtime.") {
+		return true
+	}
+	for _, p := range skipPackages {
+		if strings.HasPrefix(function, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func relativePath(file string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(file)
+}
+
+// GoldenWriter buffers the output, and compares it against testdata/<name>.golden on test cleanup.
+// With the -logtest.update flag or LOGTEST_UPDATE=1, the golden file is written instead.
+type GoldenWriter struct {
+	tb   testing.TB
+	name string
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+// Golden returns a writer which asserts the output against testdata/<name>.golden on test cleanup.
+func Golden(tb testing.TB, name string) *GoldenWriter {
+	tb.Helper()
+	w := &GoldenWriter{tb: tb, name: name}
+	tb.Cleanup(w.assert)
+	return w
+}
+
+// Write implements io.Writer.
+func (w *GoldenWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+// Sync implements zapcore.WriteSyncer.
+func (w *GoldenWriter) Sync() error { return nil }
+
+func (w *GoldenWriter) assert() {
+	w.mu.Lock()
+	got := bytes.Clone(w.buf.Bytes())
+	w.mu.Unlock()
+	AssertGolden(w.tb, w.name, got)
+}
+
+// AssertGolden compares got against testdata/<name>.golden, with the -logtest.update flag or LOGTEST_UPDATE=1, the golden file is written instead.
+func AssertGolden(tb testing.TB, name string, got []byte) {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if updateGolden() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("logtest: create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			tb.Fatalf("logtest: update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("logtest: read golden file: %v, run with -logtest.update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("logtest: output mismatch %s\n--- got:\n%s\n--- want:\n%s", path, got, want)
+	}
+}
+
+// NewGoldenLogger returns a logger which writes json at debug level to Golden(tb, name),
+// with a clock starts at GoldenTime and advances one second each entry, and the StableCaller.
+// the options are applied after the defaults.
+func NewGoldenLogger(tb testing.TB, name string, opts ...logger.Option) *logger.Log {
+	tb.Helper()
+	w := Golden(tb, name)
+	defaults := []logger.Option{
+		logger.WithLevel("debug"),
+		logger.WithFormat(logger.FormatJson),
+		logger.WithAdapter(logger.AdapterCustom, w),
+		logger.WithClock(NewClock(GoldenTime, time.Second)),
+	}
+	return logger.NewLogger(append(defaults, opts...)...).SetCaller(StableCaller)
+}
@@ -0,0 +1,37 @@
+package logtest_test
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/thinkgos/logger"
+	"github.com/thinkgos/logger/logtest"
+)
+
+// the test package may define its own -update flag.
+var _ = flag.Bool("update", false, "update the test data")
+
+func TestGolden(t *testing.T) {
+	log := logtest.NewGoldenLogger(t, "basic")
+	log.OnInfo().String("k", "v").Msg("hello")
+	log.Named("db").OnWarn().Int("rows", 3).Msg("slow query")
+	log.OnError().Msg("failed")
+}
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := logtest.NewClock(start, time.Second)
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("Now() = %v, want %v", got, start)
+	}
+	if got := clock.Now(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("Now() = %v, want %v", got, start.Add(time.Second))
+	}
+
+	log, rec := logtest.NewObserved(logger.InfoLevel)
+	log.SetCaller(logtest.StableCaller).OnError().Msg("caller")
+	if got := rec.All()[0].Caller; got != "golden_test.go:33" {
+		t.Errorf("Caller = %q, want golden_test.go:33", got)
+	}
+}
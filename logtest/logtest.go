@@ -0,0 +1,163 @@
+// Package logtest provides an in-memory observed logger for tests,
+// which records the entries emitted through logger.Log, including the fields added by hooks and the caller field.
+package logtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/thinkgos/logger"
+)
+
+// CallerKey the key of the caller field added by logger.DefaultCallerFile.
+const CallerKey = "file"
+
+// Entry is a recorded log entry.
+type Entry struct {
+	Level      logger.Level
+	Time       time.Time
+	LoggerName string
+	Message    string
+	// Caller the caller field, see logger.CallerCore, empty if the level is below the caller level.
+	Caller string
+	// Fields the fields in order, including the fields of logger.Log.With.
+	Fields []logger.Field
+}
+
+// FieldMap returns the fields as a map, the nested objects are decoded to map[string]any.
+func (e Entry) FieldMap() map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range e.Fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// String returns the entry in the format: LEVEL logger message {fields}.
+func (e Entry) String() string {
+	var b strings.Builder
+	b.WriteString(e.Level.CapitalString())
+	if e.LoggerName != "" {
+		b.WriteString(" ")
+		b.WriteString(e.LoggerName)
+	}
+	b.WriteString(" ")
+	b.WriteString(e.Message)
+	if len(e.Fields) > 0 {
+		fields, err := json.Marshal(e.FieldMap())
+		if err != nil {
+			fields = []byte(fmt.Sprint(e.FieldMap()))
+		}
+		b.WriteString(" ")
+		b.Write(fields)
+	}
+	return b.String()
+}
+
+// Recorder records the entries of the observed logger.
+// The recorder returned by the FilterXxx methods is a snapshot of the filtered entries.
+type Recorder struct {
+	logs *observer.ObservedLogs
+}
+
+// NewObserved returns a logger which records the entries at or above the level, and the recorder.
+func NewObserved(level logger.Level) (*logger.Log, *Recorder) {
+	core, logs := observer.New(level)
+	log := logger.NewLoggerWith(zap.New(core), zap.NewAtomicLevelAt(level))
+	return log, &Recorder{logs: logs}
+}
+
+// NewObservedT same as NewObserved, and writes the recorded entries to tb on test failure only.
+func NewObservedT(tb testing.TB, level logger.Level) (*logger.Log, *Recorder) {
+	log, r := NewObserved(level)
+	tb.Cleanup(func() {
+		if !tb.Failed() {
+			return
+		}
+		entries := r.All()
+		tb.Logf("logtest: %d recorded entries", len(entries))
+		for _, e := range entries {
+			tb.Log(e.String())
+		}
+	})
+	return log, r
+}
+
+// Len returns the number of the recorded entries.
+func (r *Recorder) Len() int { return r.logs.Len() }
+
+// All returns a copy of all the recorded entries.
+func (r *Recorder) All() []Entry { return toEntries(r.logs.All()) }
+
+// TakeAll returns a copy of all the recorded entries, and clears the recorder.
+func (r *Recorder) TakeAll() []Entry { return toEntries(r.logs.TakeAll()) }
+
+// FilterMessage filters the entries to those that have the specified message.
+func (r *Recorder) FilterMessage(msg string) *Recorder {
+	return &Recorder{logs: r.logs.FilterMessage(msg)}
+}
+
+// FilterMessageSnippet filters the entries to those that have a message containing the specified snippet.
+func (r *Recorder) FilterMessageSnippet(snippet string) *Recorder {
+	return &Recorder{logs: r.logs.FilterMessageSnippet(snippet)}
+}
+
+// FilterLevel filters the entries to those that have the specified level.
+func (r *Recorder) FilterLevel(level logger.Level) *Recorder {
+	return &Recorder{logs: r.logs.FilterLevelExact(level)}
+}
+
+// FilterLoggerName filters the entries to those that have the specified logger name.
+func (r *Recorder) FilterLoggerName(name string) *Recorder {
+	return &Recorder{logs: r.logs.FilterLoggerName(name)}
+}
+
+// FilterField filters the entries to those that have the specified field.
+func (r *Recorder) FilterField(field logger.Field) *Recorder {
+	return &Recorder{logs: r.logs.FilterField(field)}
+}
+
+// FilterFieldKey filters the entries to those that have the specified field key.
+func (r *Recorder) FilterFieldKey(key string) *Recorder {
+	return &Recorder{logs: r.logs.FilterFieldKey(key)}
+}
+
+// Filter filters the entries to those that the function returns true.
+func (r *Recorder) Filter(keep func(Entry) bool) *Recorder {
+	return &Recorder{logs: r.logs.Filter(func(e observer.LoggedEntry) bool { return keep(toEntry(e)) })}
+}
+
+func toEntries(logs []observer.LoggedEntry) []Entry {
+	entries := make([]Entry, 0, len(logs))
+	for _, e := range logs {
+		entries = append(entries, toEntry(e))
+	}
+	return entries
+}
+
+func toEntry(e observer.LoggedEntry) Entry {
+	entry := Entry{
+		Level:      e.Level,
+		Time:       e.Time,
+		LoggerName: e.LoggerName,
+		Message:    e.Message,
+		Fields:     e.Context,
+	}
+	for _, f := range e.Context {
+		if f.Key == CallerKey && f.Type == zapcore.StringType {
+			entry.Caller = f.String
+			break
+		}
+	}
+	if entry.Caller == "" && e.Caller.Defined {
+		entry.Caller = e.Caller.TrimmedPath()
+	}
+	return entry
+}
@@ -0,0 +1,87 @@
+package logtest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/thinkgos/logger"
+	"github.com/thinkgos/logger/logtest"
+)
+
+func TestNewObserved(t *testing.T) {
+	log, rec := logtest.NewObserved(logger.InfoLevel)
+	log = log.ExtendHookField(func(context.Context) logger.Field { return logger.String("hook", "h1") })
+
+	log.OnDebug().Msg("ignored")
+	log.OnInfo().String("k", "v").Msg("hello")
+	log.Named("db").OnWarn().Int("n", 1).Msg("slow query")
+	log.OnError().Msg("failed")
+
+	if rec.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", rec.Len())
+	}
+	entries := rec.FilterMessage("hello").All()
+	if len(entries) != 1 {
+		t.Fatalf("FilterMessage got %d entries", len(entries))
+	}
+	fields := entries[0].FieldMap()
+	if fields["k"] != "v" || fields["hook"] != "h1" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+	if got := rec.FilterField(logger.Int("n", 1)).FilterLoggerName("db").Len(); got != 1 {
+		t.Errorf("FilterField got %d entries", got)
+	}
+	if got := rec.FilterFieldKey("hook").Len(); got != 3 {
+		t.Errorf("FilterFieldKey got %d entries", got)
+	}
+	failed := rec.FilterLevel(logger.ErrorLevel).All()
+	if len(failed) != 1 || failed[0].Caller == "" {
+		t.Errorf("error entry should have the caller: %+v", failed)
+	}
+	if got := rec.Filter(func(e logtest.Entry) bool { return e.Level >= logger.WarnLevel }).Len(); got != 2 {
+		t.Errorf("Filter got %d entries", got)
+	}
+
+	if got := len(rec.TakeAll()); got != 3 || rec.Len() != 0 {
+		t.Errorf("TakeAll got %d entries, remain %d", got, rec.Len())
+	}
+}
+
+type fakeTB struct {
+	testing.TB
+	failed   bool
+	cleanups []func()
+	logs     []string
+}
+
+func (tb *fakeTB) Failed() bool     { return tb.failed }
+func (tb *fakeTB) Cleanup(f func()) { tb.cleanups = append(tb.cleanups, f) }
+func (tb *fakeTB) Log(args ...any)  { tb.logs = append(tb.logs, fmt.Sprint(args...)) }
+func (tb *fakeTB) Logf(format string, args ...any) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) cleanup() {
+	for _, f := range tb.cleanups {
+		f()
+	}
+}
+
+func TestNewObservedT(t *testing.T) {
+	passed := &fakeTB{TB: t}
+	log, _ := logtest.NewObservedT(passed, logger.InfoLevel)
+	log.OnInfo().Msg("passed")
+	passed.cleanup()
+	if len(passed.logs) != 0 {
+		t.Errorf("should not write entries on success: %v", passed.logs)
+	}
+
+	failed := &fakeTB{TB: t, failed: true}
+	log, _ = logtest.NewObservedT(failed, logger.InfoLevel)
+	log.OnInfo().String("k", "v").Msg("failed")
+	failed.cleanup()
+	if len(failed.logs) != 2 || failed.logs[1] != `INFO failed {"k":"v"}` {
+		t.Errorf("should write entries on failure: %q", failed.logs)
+	}
+}
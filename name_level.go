@@ -0,0 +1,224 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// nameLevels is the level registry keyed by the logger name path, see Log.Named.
+//
+// pattern:
+//   - exact name, e.g. "db", only match the logger named "db".
+//   - prefix wildcard, e.g. "db.*", match the loggers under "db", e.g. "db.conn", "db.conn.pool".
+//
+// the exact name has higher priority, then the longest prefix wildcard.
+type nameLevels struct {
+	mu       sync.Mutex
+	levels   map[string]Level                   // pattern -> level
+	snapshot atomic.Pointer[nameLevelsSnapshot] // nil if no override
+}
+
+// nameLevelsSnapshot is an immutable snapshot of the registry.
+type nameLevelsSnapshot struct {
+	exact    map[string]Level
+	prefixes []namePrefixLevel // sorted by prefix length desc
+	min      Level             // the minimum level of all overrides
+}
+
+type namePrefixLevel struct {
+	prefix string // with trailing dot, e.g. "db."
+	level  Level
+}
+
+func newNameLevels() *nameLevels {
+	return &nameLevels{levels: make(map[string]Level)}
+}
+
+// match returns the override level of the logger name.
+// it does not allocate, and returns false fast if no override.
+func (n *nameLevels) match(name string) (Level, bool) {
+	if n == nil {
+		return zapcore.InvalidLevel, false
+	}
+	s := n.snapshot.Load()
+	if s == nil {
+		return zapcore.InvalidLevel, false
+	}
+	if lv, ok := s.exact[name]; ok {
+		return lv, true
+	}
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.level, true
+		}
+	}
+	return zapcore.InvalidLevel, false
+}
+
+// minLevel returns the minimum level of all overrides.
+func (n *nameLevels) minLevel() (Level, bool) {
+	if n == nil {
+		return zapcore.InvalidLevel, false
+	}
+	s := n.snapshot.Load()
+	if s == nil {
+		return zapcore.InvalidLevel, false
+	}
+	return s.min, true
+}
+
+func (n *nameLevels) set(pattern string, lv Level) error {
+	if err := validNamePattern(pattern); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.levels[pattern] = lv
+	n.rebuild()
+	return nil
+}
+
+func (n *nameLevels) delete(pattern string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.levels, pattern)
+	n.rebuild()
+}
+
+// replace replaces all the overrides.
+func (n *nameLevels) replace(levels map[string]Level) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.levels = make(map[string]Level, len(levels))
+	for pattern, lv := range levels {
+		n.levels[pattern] = lv
+	}
+	n.rebuild()
+}
+
+func (n *nameLevels) all() map[string]Level {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	levels := make(map[string]Level, len(n.levels))
+	for pattern, lv := range n.levels {
+		levels[pattern] = lv
+	}
+	return levels
+}
+
+// rebuild rebuilds the snapshot, must hold the lock.
+func (n *nameLevels) rebuild() {
+	if len(n.levels) == 0 {
+		n.snapshot.Store(nil)
+		return
+	}
+	s := &nameLevelsSnapshot{
+		exact: make(map[string]Level),
+		min:   zapcore.FatalLevel,
+	}
+	for pattern, lv := range n.levels {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			s.prefixes = append(s.prefixes, namePrefixLevel{prefix: prefix, level: lv})
+		} else {
+			s.exact[pattern] = lv
+		}
+		s.min = min(s.min, lv)
+	}
+	sort.Slice(s.prefixes, func(i, j int) bool {
+		return len(s.prefixes[i].prefix) > len(s.prefixes[j].prefix)
+	})
+	n.snapshot.Store(s)
+}
+
+// validNamePattern validates the name pattern, exact name or prefix wildcard like "db.*".
+func validNamePattern(pattern string) error {
+	name, _ := strings.CutSuffix(pattern, ".*")
+	if name == "" || strings.Contains(name, "*") {
+		return fmt.Errorf("logger: invalid name pattern %q", pattern)
+	}
+	return nil
+}
+
+// parseNameLevels parses the name levels of Config.
+func parseNameLevels(levels map[string]string) (map[string]Level, error) {
+	m := make(map[string]Level, len(levels))
+	for pattern, text := range levels {
+		if err := validNamePattern(pattern); err != nil {
+			return nil, err
+		}
+		lv, err := zapcore.ParseLevel(text)
+		if err != nil {
+			return nil, err
+		}
+		m[pattern] = lv
+	}
+	return m, nil
+}
+
+// levelCore gates the entries with the logger level, or the override level of the logger name.
+type levelCore struct {
+	zapcore.Core
+	level AtomicLevel
+	names *nameLevels
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *levelCore) Enabled(lvl Level) bool {
+	if c.level.Enabled(lvl) {
+		return true
+	}
+	if m, ok := c.names.minLevel(); ok && lvl >= m {
+		return true
+	}
+	return false
+}
+
+// With implements zapcore.Core.
+func (c *levelCore) With(fields []Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level, names: c.names}
+}
+
+// Check implements zapcore.Core.
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if lv, ok := c.names.match(ent.LoggerName); ok {
+		if !lv.Enabled(ent.Level) {
+			return ce
+		}
+	} else if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// SetNameLevel set the level override for the loggers matched the pattern, see Log.Named.
+// pattern can be an exact name like "db", or a prefix wildcard like "db.*" which matches the loggers under "db".
+// It only works on the logger constructed from config.
+func (l *Log) SetNameLevel(pattern string, lv Level) error {
+	if l.pipe == nil {
+		return fmt.Errorf("logger: the logger is not constructed from config, can not set name level")
+	}
+	return l.pipe.names.set(pattern, lv)
+}
+
+// DeleteNameLevel delete the level override of the pattern.
+func (l *Log) DeleteNameLevel(pattern string) {
+	if l.pipe != nil {
+		l.pipe.names.delete(pattern)
+	}
+}
+
+// NameLevels returns all the level overrides, keyed by pattern.
+func (l *Log) NameLevels() map[string]Level {
+	if l.pipe == nil {
+		return nil
+	}
+	return l.pipe.names.all()
+}
@@ -0,0 +1,309 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var errClosed = errors.New("logger: the logger is closed")
+
+// pipeline holds the sinks built from Config.
+type pipeline struct {
+	mu      sync.RWMutex
+	conf    Config       // current applied config
+	closed  bool         // closed by Log.Close
+	names   *nameLevels  // logger name -> override level
+	limiter *rateLimiter // token buckets of Event.Every and Event.Sampled
+	core    *reloadCore
+}
+
+func newPipeline(c *Config, state *coreState, names *nameLevels) *pipeline {
+	root := &atomic.Pointer[coreState]{}
+	root.Store(state)
+	return &pipeline{
+		conf:    *c,
+		names:   names,
+		limiter: newRateLimiter(&c.Sampling),
+		core:    &reloadCore{root: root, cache: &atomic.Pointer[derivedCore]{}},
+	}
+}
+
+func (p *pipeline) sinkLevel(name string) (AtomicLevel, bool) {
+	if p == nil {
+		return AtomicLevel{}, false
+	}
+	lv, ok := p.core.root.Load().levels[name]
+	return lv, ok
+}
+
+func (p *pipeline) sinkLevels() map[string]AtomicLevel {
+	if p == nil {
+		return nil
+	}
+	state := p.core.root.Load()
+	levels := make(map[string]AtomicLevel, len(state.levels))
+	for name, lv := range state.levels {
+		levels[name] = lv
+	}
+	return levels
+}
+
+// config returns the current applied config.
+func (p *pipeline) config() Config {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.conf
+}
+
+// reload rebuilds the core with the config, and replaces the underlying core.
+// the old core is kept if failed.
+func (p *pipeline) reload(c *Config, level AtomicLevel) error {
+	lvl, err := zapcore.ParseLevel(c.Level)
+	if err != nil {
+		return err
+	}
+	nameLevels, err := parseNameLevels(c.NameLevels)
+	if err != nil {
+		return err
+	}
+	state, err := newCore(c, level, lvl, p.names)
+	if err != nil {
+		_ = state.close()
+		return err
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		_ = state.close()
+		return errClosed
+	}
+	p.conf = *c
+	level.SetLevel(lvl)
+	p.names.replace(nameLevels)
+	p.limiter.setLimit(&c.Sampling)
+	old := p.core.root.Swap(state)
+	p.mu.Unlock()
+	// the in-flight entries checked by the old core hold a reference to it,
+	// wait until they are written, then flush and close the old core.
+	<-old.retire()
+	_ = old.close()
+	state.inheritDropped(old)
+	return nil
+}
+
+// close replaces the underlying core with a no-op core, then drains and closes the owned sinks.
+// it returns the error of the context if the context is done before the sinks are closed.
+func (p *pipeline) close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	old := p.core.root.Swap(newCoreState(zapcore.NewNopCore()))
+	p.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		<-old.retire() // wait for the in-flight entries
+		done <- old.close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// coreState is the underlying core and the sinks built from Config.
+// each checked entry holds a reference to the state until it is written,
+// so the sinks are closed only after the in-flight entries are written, see retire.
+type coreState struct {
+	core    zapcore.Core
+	levels  map[string]AtomicLevel  // sink name -> sink level
+	asyncs  map[string]*asyncWriter // sink name -> async writer
+	closers []io.Closer             // the writers owned by the logger
+
+	refs      atomic.Int64  // the in-flight entries
+	retired   atomic.Bool   // replaced by reload or close
+	drained   chan struct{} // closed when retired and no in-flight entries
+	drainOnce sync.Once
+}
+
+func newCoreState(core zapcore.Core) *coreState {
+	return &coreState{core: core, drained: make(chan struct{})}
+}
+
+// release drops a reference to the state.
+func (s *coreState) release() {
+	if s.refs.Add(-1) == 0 && s.retired.Load() {
+		s.drainOnce.Do(func() { close(s.drained) })
+	}
+}
+
+// retire marks the state replaced, the returned channel is closed once the in-flight entries are written.
+// the state must have been swapped out of the root, so no more references are acquired.
+func (s *coreState) retire() <-chan struct{} {
+	s.retired.Store(true)
+	if s.refs.Load() == 0 {
+		s.drainOnce.Do(func() { close(s.drained) })
+	}
+	return s.drained
+}
+
+// close flushes the core, drains the async writers, and closes the owned writers.
+// the sync error is ignored, as os.Stdout/os.Stderr may not support it.
+func (s *coreState) close() error {
+	_ = s.core.Sync()
+	var errs []error
+	for _, w := range s.asyncs {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type derivedCore struct {
+	base *coreState
+	core zapcore.Core
+}
+
+// reloadCore is a zapcore.Core whose underlying core can be replaced at runtime.
+// all the child cores created by With share the same root,
+// and the fields are applied to the new underlying core lazily.
+type reloadCore struct {
+	root   *atomic.Pointer[coreState]
+	fields []Field
+	cache  *atomic.Pointer[derivedCore]
+}
+
+// acquire returns the current state with a reference held, the caller must release it.
+// the reference is verified against the root after acquired, so a retired state is never returned.
+func (c *reloadCore) acquire() *coreState {
+	for {
+		base := c.root.Load()
+		base.refs.Add(1)
+		if c.root.Load() == base {
+			return base
+		}
+		base.release()
+	}
+}
+
+// current returns the core derived from the state with the fields.
+func (c *reloadCore) current(base *coreState) zapcore.Core {
+	if len(c.fields) == 0 {
+		return base.core
+	}
+	if d := c.cache.Load(); d != nil && d.base == base {
+		return d.core
+	}
+	d := &derivedCore{base: base, core: base.core.With(c.fields)}
+	c.cache.Store(d)
+	return d.core
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *reloadCore) Enabled(lvl Level) bool { return c.root.Load().core.Enabled(lvl) }
+
+// With implements zapcore.Core.
+func (c *reloadCore) With(fields []Field) zapcore.Core {
+	fs := make([]Field, 0, len(c.fields)+len(fields))
+	fs = append(fs, c.fields...)
+	fs = append(fs, fields...)
+	return &reloadCore{root: c.root, fields: fs, cache: &atomic.Pointer[derivedCore]{}}
+}
+
+// Check implements zapcore.Core.
+// the checked entry holds a reference to the current state, which is released after the entry is written.
+func (c *reloadCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	base := c.acquire()
+	ce = c.current(base).Check(ent, ce)
+	if ce == nil {
+		base.release()
+		return nil
+	}
+	// the cores are written in order, so the release core is the last one.
+	return ce.AddCore(ent, releaseCore{base})
+}
+
+// Write implements zapcore.Core.
+func (c *reloadCore) Write(ent zapcore.Entry, fields []Field) error {
+	base := c.acquire()
+	defer base.release()
+	return c.current(base).Write(ent, fields)
+}
+
+// Sync implements zapcore.Core.
+func (c *reloadCore) Sync() error {
+	base := c.acquire()
+	defer base.release()
+	return base.core.Sync()
+}
+
+// releaseCore releases the reference of the state held by the checked entry after it is written.
+type releaseCore struct {
+	base *coreState
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (releaseCore) Enabled(Level) bool { return false }
+
+// With implements zapcore.Core.
+func (c releaseCore) With([]Field) zapcore.Core { return c }
+
+// Check implements zapcore.Core.
+func (releaseCore) Check(_ zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry { return ce }
+
+// Write implements zapcore.Core.
+func (c releaseCore) Write(zapcore.Entry, []Field) error {
+	c.base.release()
+	return nil
+}
+
+// Sync implements zapcore.Core.
+func (releaseCore) Sync() error { return nil }
+
+// Reload validates the config and applies it to the running logger:
+// the logger level, the caller level, the name levels and the underlying core (format, sinks, file...),
+// Config.Stack and Config.Clock can not be reloaded.
+// The current configuration is kept if failed, otherwise the old sinks are closed after the in-flight entries are written.
+func (l *Log) Reload(c Config) error {
+	if l.pipe == nil {
+		return errors.New("logger: the logger is not constructed from config, can not reload")
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if err := l.pipe.reload(&c, l.level); err != nil {
+		return err
+	}
+	l.applyCallerLevel(c.CallerLevel)
+	return nil
+}
+
+// Close flushes the async buffers, syncs and closes all the sinks owned by the logger
+// (file, rotate file and the sinks built by the registered factory),
+// the writers provided by the caller (Config.Writer, os.Stdout, os.Stderr) are not closed.
+// It returns the error of the context if the context is done before the sinks are closed.
+// After Close, further logging is a no-op and Reload returns an error,
+// the logger shares the sinks with its children, so they are closed too.
+func (l *Log) Close(ctx context.Context) error {
+	if l.pipe == nil {
+		return l.log.Sync()
+	}
+	return l.pipe.close(ctx)
+}
@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// rate limit default defined
+const (
+	defaultSampledRate  = 1.0
+	defaultSampledBurst = 1
+)
+
+// suppressedKey the field key which reports how many events were suppressed since the last emitted one.
+const suppressedKey = "suppressed"
+
+// bucketIdle the idle time after which the refilled token bucket is evicted.
+const bucketIdle = time.Minute
+
+type limitKey struct {
+	pc   uintptr       // call site of Event.Every
+	d    time.Duration // interval of Event.Every
+	name string        // key of Event.Sampled
+}
+
+type limit struct {
+	rate  float64 // tokens per second
+	burst int
+}
+
+// rateLimiter holds the token buckets keyed by the message key.
+type rateLimiter struct {
+	limit     atomic.Pointer[limit] // limit of Event.Sampled
+	mu        sync.RWMutex
+	buckets   map[limitKey]*bucket
+	lastSweep atomic.Int64 // unix nano of the last eviction of the idle buckets
+}
+
+func newRateLimiter(sc *SamplingConfig) *rateLimiter {
+	l := &rateLimiter{buckets: make(map[limitKey]*bucket)}
+	l.setLimit(sc)
+	l.lastSweep.Store(time.Now().UnixNano())
+	return l
+}
+
+func (l *rateLimiter) setLimit(sc *SamplingConfig) {
+	lim := &limit{rate: sc.Rate, burst: sc.Burst}
+	if lim.rate <= 0 {
+		lim.rate = defaultSampledRate
+	}
+	if lim.burst <= 0 {
+		lim.burst = defaultSampledBurst
+	}
+	l.limit.Store(lim)
+}
+
+// allow reports whether the event of the key is allowed,
+// and the number of suppressed events since the last allowed one.
+// the bucket of Event.Every has its own limit, others use the limit of Event.Sampled.
+func (l *rateLimiter) allow(key limitKey) (bool, uint64) {
+	now := time.Now()
+	l.sweep(now)
+	l.mu.RLock()
+	b, ok := l.buckets[key]
+	l.mu.RUnlock()
+	if !ok {
+		l.mu.Lock()
+		if b, ok = l.buckets[key]; !ok {
+			b = &bucket{}
+			if key.d > 0 {
+				b.every = &limit{rate: 1 / key.d.Seconds(), burst: 1}
+			}
+			b.tokens = float64(l.limitOf(b).burst)
+			l.buckets[key] = b
+		}
+		l.mu.Unlock()
+	}
+	return b.take(now, l.limitOf(b))
+}
+
+func (l *rateLimiter) limitOf(b *bucket) *limit {
+	if b.every != nil {
+		return b.every
+	}
+	return l.limit.Load()
+}
+
+// sweep evicts the buckets which are idle for bucketIdle and refilled, at most once per bucketIdle,
+// a new bucket is created when the key is used again,
+// NOTICE: the suppressed count of the evicted bucket is discarded.
+func (l *rateLimiter) sweep(now time.Time) {
+	last := l.lastSweep.Load()
+	if now.UnixNano()-last < int64(bucketIdle) || !l.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, b := range l.buckets {
+		if b.idle(now, l.limitOf(b)) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// bucket is a token bucket.
+type bucket struct {
+	mu         sync.Mutex
+	every      *limit // limit of Event.Every, nil for Event.Sampled
+	tokens     float64
+	last       time.Time
+	suppressed uint64
+}
+
+func (b *bucket) take(now time.Time, lim *limit) (bool, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*lim.rate, float64(lim.burst))
+	}
+	b.last = now
+	if b.tokens < 1 {
+		b.suppressed++
+		return false, 0
+	}
+	b.tokens--
+	suppressed := b.suppressed
+	b.suppressed = 0
+	return true, suppressed
+}
+
+// idle reports whether the bucket is unused for bucketIdle, and refilled as a new one.
+func (b *bucket) idle(now time.Time, lim *limit) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elapsed := now.Sub(b.last)
+	return elapsed >= bucketIdle && b.tokens+elapsed.Seconds()*lim.rate >= float64(lim.burst)
+}
+
+// Every limits the event to at most one per d for the call site,
+// the number of suppressed events is reported as the "suppressed" field of the next emitted event.
+// It returns nil if the event is suppressed, so the following calls on the event are skipped.
+// d <= 0 means no limit.
+func (e *Event) Every(d time.Duration) *Event {
+	if e == nil || d <= 0 {
+		return e
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // runtime.Caller allocates, the program counter is enough for the key
+	return e.limit(limitKey{pc: pcs[0], d: d})
+}
+
+// Sampled limits the events of the key with a token bucket, which rate and burst configured by Config.Sampling,
+// the number of suppressed events is reported as the "suppressed" field of the next emitted event.
+// It returns nil if the event is suppressed, so the following calls on the event are skipped.
+// NOTICE: the key should be bounded, each key holds a token bucket until it is idle and refilled, see bucketIdle.
+func (e *Event) Sampled(key string) *Event {
+	if e == nil {
+		return e
+	}
+	return e.limit(limitKey{name: key})
+}
+
+func (e *Event) limit(key limitKey) *Event {
+	ok, suppressed := e.log.limiter.allow(key)
+	if !ok {
+		putEvent(e)
+		return nil
+	}
+	if suppressed > 0 {
+		e.fields = append(e.fields, zap.Uint64(suppressedKey, suppressed))
+	}
+	return e
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// recover field key defined
+const (
+	PanicKey = "panic"
+	StackKey = "stack"
+)
+
+type recoverOptions struct {
+	ctx     context.Context
+	repanic bool
+	fields  []Field
+}
+
+// RecoverOption the option of Log.Recover and Log.Go.
+type RecoverOption func(o *recoverOptions)
+
+// WithRepanic re-panics with the panic value after logging.
+func WithRepanic() RecoverOption {
+	return func(o *recoverOptions) { o.repanic = true }
+}
+
+// WithRecoverFields adds the fields to the panic log.
+func WithRecoverFields(fields ...Field) RecoverOption {
+	return func(o *recoverOptions) { o.fields = append(o.fields, fields...) }
+}
+
+// WithRecoverContext set the context of Log.Go, the fields carried by the context are logged.
+func WithRecoverContext(ctx context.Context) RecoverOption {
+	return func(o *recoverOptions) { o.ctx = ctx }
+}
+
+// Recover recovers the panic, and logs it at error level with the panic value, the stack and the context fields.
+// It must be called directly by defer:
+//
+//	defer log.Recover(ctx)
+func (l *Log) Recover(ctx context.Context, opts ...RecoverOption) {
+	if v := recover(); v != nil {
+		l.handlePanic(ctx, v, opts, 2) // skip handlePanic and Recover
+	}
+}
+
+// LogPanic logs the recovered panic value like Log.Recover, used when the caller recovers the panic itself,
+// such as the middleware which handles the specific panic values.
+// It must be called directly by the deferred function, so the stack starts from the panic.
+//
+//	defer func() {
+//		if v := recover(); v != nil {
+//			log.LogPanic(ctx, v)
+//		}
+//	}()
+func (l *Log) LogPanic(ctx context.Context, v any, opts ...RecoverOption) {
+	l.handlePanic(ctx, v, opts, 3) // skip handlePanic, LogPanic and the deferred function
+}
+
+// Go runs the function in a goroutine, the panic is recovered and logged, see Log.Recover.
+func (l *Log) Go(fn func(), opts ...RecoverOption) {
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				l.handlePanic(nil, v, opts, 2) // skip handlePanic and the deferred function
+			}
+		}()
+		fn()
+	}()
+}
+
+// handlePanic logs the panic, skip is the number of the frames above the panic to skip in the stack.
+func (l *Log) handlePanic(ctx context.Context, v any, opts []RecoverOption, skip int) {
+	o := recoverOptions{ctx: ctx}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.ctx == nil {
+		o.ctx = context.Background()
+	}
+	l.OnLevelContext(o.ctx, ErrorLevel).
+		With(zap.Any(PanicKey, v)).
+		With(o.fields...).
+		StackSkip(StackKey, skip).
+		Msg("panic recovered")
+	if o.repanic {
+		_ = l.Sync()
+		panic(v)
+	}
+}
@@ -0,0 +1,554 @@
+package logger
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// redact action defined
+const (
+	RedactMask = "mask" // replace the value with RedactConfig.Mask
+	RedactHash = "hash" // replace the value with the prefix of its sha256 hash
+	RedactDrop = "drop" // drop the field
+)
+
+// builtin value pattern defined
+const (
+	RedactCreditCard = "credit-card" // credit card number, which passes the Luhn check
+	RedactBearer     = "bearer"      // bearer token
+	RedactEmail      = "email"       // email address
+)
+
+const defaultRedactMask = "******"
+
+var builtinRedactValues = map[string]string{
+	RedactCreditCard: `\b(?:\d[ -]?){12,18}\d\b`,
+	RedactBearer:     `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`,
+	RedactEmail:      `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+}
+
+// builtinRedactChecks verifies the match of the builtin value pattern,
+// such as the digits of timestamps and order numbers are not credit card numbers.
+var builtinRedactChecks = map[string]func(string) bool{
+	RedactCreditCard: luhnValid,
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum, the other characters are ignored.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// valuePattern the regex of the sensitive value, the match is redacted only if check passes.
+type valuePattern struct {
+	re    *regexp.Regexp
+	check func(string) bool // optional
+}
+
+// redactor redacts the sensitive fields by key names, key glob patterns and value regexes.
+type redactor struct {
+	keys     map[string]struct{} // lower case
+	patterns []string            // lower case
+	values   []valuePattern
+	action   string
+	mask     string
+	types    sync.Map // reflect.Type -> bool, whether the reflected value of the type may be redacted
+}
+
+// newRedactor returns nil if no key, pattern or value need to redact.
+func newRedactor(rc *RedactConfig) (*redactor, error) {
+	if len(rc.Keys) == 0 && len(rc.Patterns) == 0 && len(rc.Values) == 0 {
+		return nil, nil
+	}
+	r := &redactor{
+		keys:   make(map[string]struct{}, len(rc.Keys)),
+		action: cmp.Or(rc.Action, RedactMask),
+		mask:   cmp.Or(rc.Mask, defaultRedactMask),
+	}
+	for _, key := range rc.Keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+	}
+	for _, pattern := range rc.Patterns {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("logger: redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	for _, value := range rc.Values {
+		expr, ok := builtinRedactValues[value]
+		if !ok {
+			expr = value
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("logger: redact value %q: %w", value, err)
+		}
+		r.values = append(r.values, valuePattern{re: re, check: builtinRedactChecks[value]})
+	}
+	return r, nil
+}
+
+// newTagRedactor returns the redactor which only handles the struct fields tagged with redact, see Struct,
+// it returns nil if neither Action nor Mask is set, the tagged fields are replaced with ****** then.
+func newTagRedactor(rc *RedactConfig) *redactor {
+	if rc.Action == "" && rc.Mask == "" {
+		return nil
+	}
+	return &redactor{action: cmp.Or(rc.Action, RedactMask), mask: cmp.Or(rc.Mask, defaultRedactMask)}
+}
+
+// tagOnly reports whether the redactor only handles the struct fields tagged with redact.
+func (r *redactor) tagOnly() bool {
+	return len(r.keys) == 0 && len(r.patterns) == 0 && len(r.values) == 0
+}
+
+// matchKey reports whether the key is sensitive,
+// both the full key and the last segment of the dotted key (such as the slog group attrs) are matched.
+func (r *redactor) matchKey(key string) bool {
+	if key == "" || (len(r.keys) == 0 && len(r.patterns) == 0) {
+		return false
+	}
+	key = strings.ToLower(key)
+	if r.matchName(key) {
+		return true
+	}
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		return r.matchName(key[i+1:])
+	}
+	return false
+}
+
+func (r *redactor) matchName(name string) bool {
+	if _, ok := r.keys[name]; ok {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactString redacts the substrings matched the value regexes, returns false if the value should be dropped.
+func (r *redactor) redactString(s string) (string, bool, bool) {
+	changed := false
+	for _, v := range r.values {
+		if !v.re.MatchString(s) {
+			continue
+		}
+		matched := false
+		out := v.re.ReplaceAllStringFunc(s, func(m string) string {
+			if v.check != nil && !v.check(m) {
+				return m
+			}
+			matched = true
+			return r.replace(m)
+		})
+		if !matched {
+			continue
+		}
+		if r.action == RedactDrop {
+			return "", true, false
+		}
+		s, changed = out, true
+	}
+	return s, changed, true
+}
+
+// replace returns the replacement of the sensitive value.
+func (r *redactor) replace(s string) string {
+	if r.action == RedactHash {
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	}
+	return r.mask
+}
+
+// redactField returns the redacted field, returns false if the field should be dropped.
+func (r *redactor) redactField(f Field) (Field, bool) {
+	switch f.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return f, true
+	}
+	if r.matchKey(f.Key) {
+		switch r.action {
+		case RedactDrop:
+			return f, false
+		case RedactHash:
+			return zap.String(f.Key, r.replace(fieldValue(f))), true
+		default:
+			return zap.String(f.Key, r.mask), true
+		}
+	}
+
+	hasValues := len(r.values) > 0 // the string of the value is computed only if it may be redacted
+	switch f.Type {
+	case zapcore.StringType:
+		if s, changed, ok := r.redactString(f.String); !ok || changed {
+			return zap.String(f.Key, s), ok
+		}
+	case zapcore.ByteStringType:
+		if !hasValues {
+			break
+		}
+		if s, changed, ok := r.redactString(string(f.Interface.([]byte))); !ok || changed {
+			return zap.String(f.Key, s), ok
+		}
+	case zapcore.StringerType:
+		if !hasValues {
+			break
+		}
+		if s, changed, ok := r.redactString(fieldValue(f)); !ok || changed {
+			return zap.String(f.Key, s), ok
+		}
+	case zapcore.ErrorType:
+		if !hasValues {
+			break
+		}
+		if err, ok := f.Interface.(error); ok && err != nil {
+			if s, changed, ok := r.redactString(err.Error()); !ok || changed {
+				return zap.String(f.Key, s), ok
+			}
+		}
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		f.Interface = redactObject{ObjectMarshaler: f.Interface.(zapcore.ObjectMarshaler), r: r}
+	case zapcore.ArrayMarshalerType:
+		f.Interface = redactArray{ArrayMarshaler: f.Interface.(zapcore.ArrayMarshaler), r: r}
+	case zapcore.ReflectType:
+		if v, changed, ok := r.redactReflected(f.Interface); !ok || changed {
+			return zap.Reflect(f.Key, v), ok
+		}
+	}
+	return f, true
+}
+
+// redactReflected redacts the value by json round trip,
+// returns the value itself if nothing changed.
+// the round trip is skipped if no key, pattern or value can match the type of the value.
+func (r *redactor) redactReflected(v any) (any, bool, bool) {
+	if v == nil || r.tagOnly() || !r.mayRedact(reflect.TypeOf(v)) {
+		return v, false, true
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v, false, true
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var val any
+	if err = dec.Decode(&val); err != nil {
+		return v, false, true
+	}
+	val, changed, ok := r.redactValue(val)
+	if !changed {
+		return v, false, ok
+	}
+	return val, changed, ok
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// mayRedact reports whether the json encoding of the type may contain the sensitive keys or values, the result is cached.
+func (r *redactor) mayRedact(t reflect.Type) bool {
+	if v, ok := r.types.Load(t); ok {
+		return v.(bool)
+	}
+	may := r.typeMayRedact(t, make(map[reflect.Type]bool))
+	r.types.Store(t, may)
+	return may
+}
+
+func (r *redactor) typeMayRedact(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] { // recursive type, decided by the other fields
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	hasKeys := len(r.keys) > 0 || len(r.patterns) > 0
+	if t == timeType {
+		return false
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return true // unknown output
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return len(r.values) > 0
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return len(r.values) > 0
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return r.typeMayRedact(t.Elem(), visiting)
+	case reflect.Map:
+		return hasKeys || len(r.values) > 0 || r.typeMayRedact(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() && !sf.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" && !sf.Anonymous {
+				name = sf.Name
+			}
+			if name != "" && r.matchKey(name) {
+				return true
+			}
+			if r.typeMayRedact(sf.Type, visiting) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func (r *redactor) redactValue(v any) (any, bool, bool) {
+	switch vv := v.(type) {
+	case string:
+		return r.redactString(vv)
+	case map[string]any:
+		changed := false
+		for key, val := range vv {
+			if r.matchKey(key) {
+				changed = true
+				switch r.action {
+				case RedactDrop:
+					delete(vv, key)
+				case RedactHash:
+					vv[key] = r.replace(fmt.Sprint(val))
+				default:
+					vv[key] = r.mask
+				}
+				continue
+			}
+			val, c, ok := r.redactValue(val)
+			if !ok {
+				delete(vv, key)
+			} else if c {
+				vv[key] = val
+			}
+			changed = changed || c || !ok
+		}
+		return vv, changed, true
+	case []any:
+		changed := false
+		out := vv[:0]
+		for _, val := range vv {
+			val, c, ok := r.redactValue(val)
+			if ok {
+				out = append(out, val)
+			}
+			changed = changed || c || !ok
+		}
+		return out, changed, true
+	default:
+		return v, false, true
+	}
+}
+
+// fieldValue returns the string representation of the field value.
+func fieldValue(f Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return fmt.Sprint(enc.Fields[f.Key])
+}
+
+// redactEncoder is a zapcore.Encoder which redacts the sensitive fields before encoding,
+// both the context fields and the entry fields.
+type redactEncoder struct {
+	redactObjectEncoder
+	enc zapcore.Encoder
+}
+
+func newRedactEncoder(enc zapcore.Encoder, r *redactor) *redactEncoder {
+	return &redactEncoder{redactObjectEncoder: redactObjectEncoder{ObjectEncoder: enc, r: r}, enc: enc}
+}
+
+// Clone implements zapcore.Encoder.
+func (e *redactEncoder) Clone() zapcore.Encoder { return newRedactEncoder(e.enc.Clone(), e.r) }
+
+// EncodeEntry implements zapcore.Encoder.
+func (e *redactEncoder) EncodeEntry(ent zapcore.Entry, fields []Field) (*buffer.Buffer, error) {
+	if e.r.tagOnly() && !hasMarshaler(fields) {
+		return e.enc.EncodeEntry(ent, fields)
+	}
+	redacted := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		if f, ok := e.r.redactField(f); ok {
+			redacted = append(redacted, f)
+		}
+	}
+	return e.enc.EncodeEntry(ent, redacted)
+}
+
+// hasMarshaler reports whether the fields contain an object or array which may be a struct tagged with redact.
+func hasMarshaler(fields []Field) bool {
+	for i := range fields {
+		switch fields[i].Type {
+		case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType, zapcore.ArrayMarshalerType:
+			return true
+		}
+	}
+	return false
+}
+
+// redactObject is a zapcore.ObjectMarshaler which redacts the nested fields.
+type redactObject struct {
+	zapcore.ObjectMarshaler
+	r *redactor
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (o redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(&redactObjectEncoder{ObjectEncoder: enc, r: o.r})
+}
+
+// redactArray is a zapcore.ArrayMarshaler which redacts the nested elements.
+type redactArray struct {
+	zapcore.ArrayMarshaler
+	r *redactor
+}
+
+// MarshalLogArray implements zapcore.ArrayMarshaler.
+func (a redactArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.ArrayMarshaler.MarshalLogArray(&redactArrayEncoder{ArrayEncoder: enc, r: a.r})
+}
+
+// redactObjectEncoder is a zapcore.ObjectEncoder which redacts the fields added.
+type redactObjectEncoder struct {
+	zapcore.ObjectEncoder
+	r *redactor
+}
+
+func (e *redactObjectEncoder) add(f Field) {
+	if f, ok := e.r.redactField(f); ok {
+		f.AddTo(e.ObjectEncoder)
+	}
+}
+
+// addErr adds the redacted field, and returns the error of encoding the object, the array or the reflected value.
+func (e *redactObjectEncoder) addErr(f Field) error {
+	f, ok := e.r.redactField(f)
+	if !ok {
+		return nil
+	}
+	switch f.Type {
+	case zapcore.ArrayMarshalerType:
+		return e.ObjectEncoder.AddArray(f.Key, f.Interface.(zapcore.ArrayMarshaler))
+	case zapcore.ObjectMarshalerType:
+		return e.ObjectEncoder.AddObject(f.Key, f.Interface.(zapcore.ObjectMarshaler))
+	case zapcore.ReflectType:
+		return e.ObjectEncoder.AddReflected(f.Key, f.Interface)
+	}
+	f.AddTo(e.ObjectEncoder)
+	return nil
+}
+
+func (e *redactObjectEncoder) AddArray(k string, v zapcore.ArrayMarshaler) error {
+	return e.addErr(zap.Array(k, v))
+}
+func (e *redactObjectEncoder) AddObject(k string, v zapcore.ObjectMarshaler) error {
+	return e.addErr(zap.Object(k, v))
+}
+func (e *redactObjectEncoder) AddReflected(k string, v any) error {
+	return e.addErr(zap.Reflect(k, v))
+}
+func (e *redactObjectEncoder) AddBinary(k string, v []byte)          { e.add(zap.Binary(k, v)) }
+func (e *redactObjectEncoder) AddByteString(k string, v []byte)      { e.add(zap.ByteString(k, v)) }
+func (e *redactObjectEncoder) AddBool(k string, v bool)              { e.add(zap.Bool(k, v)) }
+func (e *redactObjectEncoder) AddComplex128(k string, v complex128)  { e.add(zap.Complex128(k, v)) }
+func (e *redactObjectEncoder) AddComplex64(k string, v complex64)    { e.add(zap.Complex64(k, v)) }
+func (e *redactObjectEncoder) AddDuration(k string, v time.Duration) { e.add(zap.Duration(k, v)) }
+func (e *redactObjectEncoder) AddFloat64(k string, v float64)        { e.add(zap.Float64(k, v)) }
+func (e *redactObjectEncoder) AddFloat32(k string, v float32)        { e.add(zap.Float32(k, v)) }
+func (e *redactObjectEncoder) AddInt(k string, v int)                { e.add(zap.Int(k, v)) }
+func (e *redactObjectEncoder) AddInt64(k string, v int64)            { e.add(zap.Int64(k, v)) }
+func (e *redactObjectEncoder) AddInt32(k string, v int32)            { e.add(zap.Int32(k, v)) }
+func (e *redactObjectEncoder) AddInt16(k string, v int16)            { e.add(zap.Int16(k, v)) }
+func (e *redactObjectEncoder) AddInt8(k string, v int8)              { e.add(zap.Int8(k, v)) }
+func (e *redactObjectEncoder) AddString(k, v string)                 { e.add(zap.String(k, v)) }
+func (e *redactObjectEncoder) AddTime(k string, v time.Time)         { e.add(zap.Time(k, v)) }
+func (e *redactObjectEncoder) AddUint(k string, v uint)              { e.add(zap.Uint(k, v)) }
+func (e *redactObjectEncoder) AddUint64(k string, v uint64)          { e.add(zap.Uint64(k, v)) }
+func (e *redactObjectEncoder) AddUint32(k string, v uint32)          { e.add(zap.Uint32(k, v)) }
+func (e *redactObjectEncoder) AddUint16(k string, v uint16)          { e.add(zap.Uint16(k, v)) }
+func (e *redactObjectEncoder) AddUint8(k string, v uint8)            { e.add(zap.Uint8(k, v)) }
+func (e *redactObjectEncoder) AddUintptr(k string, v uintptr)        { e.add(zap.Uintptr(k, v)) }
+
+// redactArrayEncoder is a zapcore.ArrayEncoder which redacts the elements appended.
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	r *redactor
+}
+
+func (e *redactArrayEncoder) AppendString(v string) {
+	if s, _, ok := e.r.redactString(v); ok {
+		e.ArrayEncoder.AppendString(s)
+	}
+}
+func (e *redactArrayEncoder) AppendByteString(v []byte) {
+	if s, changed, ok := e.r.redactString(string(v)); !ok {
+		return
+	} else if changed {
+		e.ArrayEncoder.AppendString(s)
+		return
+	}
+	e.ArrayEncoder.AppendByteString(v)
+}
+func (e *redactArrayEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactObject{ObjectMarshaler: v, r: e.r})
+}
+func (e *redactArrayEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactArray{ArrayMarshaler: v, r: e.r})
+}
+func (e *redactArrayEncoder) AppendReflected(v any) error {
+	if v, _, ok := e.r.redactReflected(v); ok {
+		return e.ArrayEncoder.AppendReflected(v)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec compresses the rotated log files.
+type Codec interface {
+	// Ext returns the extension of the compressed file, e.g. ".gz".
+	Ext() string
+	// NewWriter returns a writer which compresses the data to w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// extensions of the built-in codecs, the cleanup recognizes all of them,
+// even if the codec has been changed.
+var codecExts = []string{".gz", ".zst"}
+
+// GzipCodec compresses the files with gzip.
+type GzipCodec struct {
+	// Level is the gzip compression level, 0 means gzip.DefaultCompression.
+	Level int
+}
+
+// Ext implements Codec.
+func (GzipCodec) Ext() string { return ".gz" }
+
+// NewWriter implements Codec.
+func (c GzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+// ZstdCodec compresses the files with zstd, it's pure go.
+type ZstdCodec struct {
+	// Level is the zstd compression level, 1-22, 0 means the default level.
+	Level int
+}
+
+// Ext implements Codec.
+func (ZstdCodec) Ext() string { return ".zst" }
+
+// NewWriter implements Codec.
+func (c ZstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	if c.Level > 0 {
+		opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.Level)))
+	}
+	return zstd.NewWriter(w, opts...)
+}
+
+// ParseCodec parses the codec, the format is name[:level].
+//
+//	none       no compression, returns nil
+//	gzip       gzip with default level, gzip:1 - gzip:9 with the level
+//	zstd       zstd with default level, zstd:1 - zstd:22 with the level
+func ParseCodec(s string) (Codec, error) {
+	name, levelText, hasLevel := strings.Cut(strings.ToLower(s), ":")
+	level := 0
+	if hasLevel {
+		var err error
+		level, err = strconv.Atoi(levelText)
+		if err != nil {
+			return nil, fmt.Errorf("rotate: invalid compression level %q", s)
+		}
+	}
+	switch name {
+	case "", "none":
+		if hasLevel {
+			return nil, fmt.Errorf("rotate: invalid compression %q", s)
+		}
+		return nil, nil
+	case "gzip":
+		if hasLevel && (level < gzip.BestSpeed || level > gzip.BestCompression) {
+			return nil, fmt.Errorf("rotate: gzip compression level %q must be 1-9", s)
+		}
+		return GzipCodec{Level: level}, nil
+	case "zstd":
+		if hasLevel && (level < 1 || level > 22) {
+			return nil, fmt.Errorf("rotate: zstd compression level %q must be 1-22", s)
+		}
+		return ZstdCodec{Level: level}, nil
+	default:
+		return nil, fmt.Errorf("rotate: unsupported compression %q", s)
+	}
+}
@@ -0,0 +1,591 @@
+// Package rotate provides a rolling file writer, which rotates the file by size and time interval.
+//
+// It keeps the semantics of lumberjack (MaxSize, MaxAge, MaxBackups, LocalTime, Compress),
+// and adds the time based rotation, the filename pattern with strftime-style placeholders
+// and the pluggable compression codecs.
+//
+// Writer assumes that only one process is writing to the output files.
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	defaultMaxSize   = 100
+)
+
+// megabyte exists so it can be mocked out by tests.
+var megabyte = 1024 * 1024
+
+// interval defined
+const (
+	Hourly = time.Hour
+	Daily  = 24 * time.Hour
+)
+
+// currentTime exists so it can be mocked out by tests.
+var currentTime = time.Now
+
+// ensure we always implement io.WriteCloser
+var _ io.WriteCloser = (*Writer)(nil)
+
+// Writer is an io.WriteCloser that writes to the file, and rotates the file by size and time interval.
+//
+// Without Pattern, the current file is always Filename, the rotated file is renamed
+// by putting the rotation time in the name immediately before the file's extension,
+// e.g. app-2006-01-02T15-04-05.000.log, same as lumberjack.
+//
+// With Pattern, the current file is named by formatting the Pattern with the start time of
+// the current interval, e.g. app-%Y-%m-%d.log -> app-2026-10-18.log. When the file exceeds
+// MaxSize within the interval, it is renamed by appending a sequence, e.g. app-2026-10-18.log.1.
+type Writer struct {
+	// Filename is the file to write logs to, used when Pattern is empty.
+	// Backup log files will be retained in the same directory.
+	// It uses <processname>-lumberjack.log in os.TempDir() if empty.
+	Filename string
+	// Pattern is the filename with strftime-style placeholders, see ValidatePattern,
+	// the placeholders are only allowed in the base name.
+	Pattern string
+	// Interval is the time interval to rotate the file, 0 means rotating by size only.
+	// Daily interval is aligned to the midnight, and hourly interval is aligned to the hour.
+	Interval time.Duration
+	// MaxSize is the maximum size in megabytes of the log file before it gets rotated.
+	// It defaults to 100 megabytes.
+	MaxSize int
+	// MaxAge is the maximum number of days to retain old log files based on the
+	// modification time. The default is not to remove old log files based on age.
+	MaxAge int
+	// MaxBackups is the maximum number of old log files to retain. The default
+	// is to retain all old log files (though MaxAge may still cause them to get deleted.)
+	MaxBackups int
+	// MaxTotalSize is the maximum total size in megabytes of the current file and
+	// the old log files (compressed or not), the oldest old log files are removed
+	// when exceeded. The default is no limit.
+	MaxTotalSize int
+	// LocalTime determines if the time used for formatting the timestamps in
+	// names is the computer's local time. The default is to use UTC time.
+	LocalTime bool
+	// Compress determines if the rotated log files should be compressed using gzip.
+	// It is ignored if Compression is set.
+	Compress bool
+	// Compression is the codec to compress the rotated log files, see ParseCodec.
+	// nil means no compression, unless Compress is true.
+	Compression Codec
+
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	name      string    // name of the current file
+	periodEnd time.Time // zero if no time rotation
+	millMu    sync.Mutex
+	millWg    sync.WaitGroup
+}
+
+// ParseInterval parses the rotation interval, hourly, daily or a duration like 30m, 12h.
+// Empty string means no time rotation.
+func ParseInterval(s string) (time.Duration, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return 0, nil
+	case "hourly":
+		return Hourly, nil
+	case "daily":
+		return Daily, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("rotate: invalid interval %q", s)
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("rotate: interval %q must be at least 1s", s)
+	}
+	return d, nil
+}
+
+// Write implements io.Writer. If a write would cause the file to exceed MaxSize,
+// or the time interval elapsed, the file is rotated.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	writeLen := int64(len(p))
+	if writeLen > w.max() {
+		return 0, fmt.Errorf("rotate: write length %d exceeds maximum file size %d", writeLen, w.max())
+	}
+	if w.file == nil {
+		if err = w.openExistingOrNew(len(p)); err != nil {
+			return 0, err
+		}
+	} else if !w.periodEnd.IsZero() && !currentTime().Before(w.periodEnd) {
+		if err = w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if w.size+writeLen > w.max() {
+		if err = w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Sync commits the current contents of the file to stable storage.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close implements io.Closer, and closes the current file,
+// it waits for the background compression and cleanup finished.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	err := w.close()
+	w.mu.Unlock()
+	w.millWg.Wait()
+	return err
+}
+
+// close closes the file if it is open.
+func (w *Writer) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// Rotate causes Writer to close the existing file and immediately create a new one.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// rotate closes the current file, moves it aside or switches to the file of the new interval,
+// and then runs the post-rotation processing and removal.
+func (w *Writer) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+	if err := w.openNew(); err != nil {
+		return err
+	}
+	w.mill()
+	return nil
+}
+
+// openNew opens a new log file for writing, moving any old log file out of the way.
+func (w *Writer) openNew() error {
+	now := currentTime()
+	start, end := w.period(now)
+	name := w.filename(start)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("rotate: can't make directories for new logfile: %w", err)
+	}
+
+	mode := os.FileMode(0600)
+	info, err := os.Stat(name)
+	if err == nil {
+		// Copy the mode off the old logfile.
+		mode = info.Mode()
+		// move the existing file
+		newname, err := w.backupName(name, now)
+		if err != nil {
+			return err
+		}
+		if err = os.Rename(name, newname); err != nil {
+			return fmt.Errorf("rotate: can't rename log file: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("rotate: can't open new logfile: %w", err)
+	}
+	w.file = f
+	w.size = 0
+	w.name = name
+	w.periodEnd = end
+	return nil
+}
+
+// openExistingOrNew opens the current file if it exists and if the current write
+// would not put it over MaxSize. If there is no such file or the write would
+// put it over the MaxSize, a new file is created.
+func (w *Writer) openExistingOrNew(writeLen int) error {
+	if err := w.openExisting(writeLen); err != nil {
+		return err
+	}
+	w.mill()
+	return nil
+}
+
+func (w *Writer) openExisting(writeLen int) error {
+	start, end := w.period(currentTime())
+	name := w.filename(start)
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return w.openNew()
+	}
+	if err != nil {
+		return fmt.Errorf("rotate: error getting log file info: %w", err)
+	}
+	// the file is full, or it belongs to the previous interval.
+	if info.Size()+int64(writeLen) >= w.max() || (!end.IsZero() && info.ModTime().Before(start)) {
+		return w.openNew()
+	}
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		// if we fail to open the old log file for some reason, just ignore
+		// it and open a new log file.
+		return w.openNew()
+	}
+	w.file = file
+	w.size = info.Size()
+	w.name = name
+	w.periodEnd = end
+	return nil
+}
+
+// period returns the time interval which contains t, end is zero if no time rotation.
+func (w *Writer) period(t time.Time) (start, end time.Time) {
+	if w.LocalTime {
+		t = t.Local()
+	} else {
+		t = t.UTC()
+	}
+	if w.Interval <= 0 {
+		return t, time.Time{}
+	}
+	switch {
+	case w.Interval%Daily == 0:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 0, int(w.Interval/Daily))
+	case w.Interval%Hourly == 0 && Daily%w.Interval == 0:
+		hours := int(w.Interval / Hourly)
+		start = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()-t.Hour()%hours, 0, 0, 0, t.Location())
+		return start, start.Add(w.Interval)
+	default:
+		start = t.Truncate(w.Interval)
+		return start, start.Add(w.Interval)
+	}
+}
+
+// filename returns the name of current file which start at t.
+func (w *Writer) filename(t time.Time) string {
+	if w.Pattern != "" {
+		return strftime(w.Pattern, t)
+	}
+	if w.Filename != "" {
+		return w.Filename
+	}
+	name := filepath.Base(os.Args[0]) + "-lumberjack.log"
+	return filepath.Join(os.TempDir(), name)
+}
+
+// backupName returns the name of the backup file of the current file.
+func (w *Writer) backupName(name string, t time.Time) (string, error) {
+	if w.Pattern != "" {
+		// append the sequence
+		for seq := 1; ; seq++ {
+			newname := name + "." + strconv.Itoa(seq)
+			if _, err := os.Stat(newname); os.IsNotExist(err) {
+				return newname, nil
+			} else if err != nil {
+				return "", err
+			}
+		}
+	}
+	if !w.LocalTime {
+		t = t.UTC()
+	}
+	dir := filepath.Dir(name)
+	prefix, ext := prefixAndExt(name)
+	return filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext), nil
+}
+
+// max returns the maximum size in bytes of the file.
+func (w *Writer) max() int64 {
+	if w.MaxSize == 0 {
+		return int64(defaultMaxSize * megabyte)
+	}
+	return int64(w.MaxSize) * int64(megabyte)
+}
+
+// dir returns the directory of the files.
+func (w *Writer) dir() string {
+	if w.Pattern != "" {
+		dir, _ := splitPath(w.Pattern)
+		return dir
+	}
+	return filepath.Dir(w.filename(time.Time{}))
+}
+
+// backupRegexp returns the regexp which matches the backup files in dir.
+func (w *Writer) backupRegexp() *regexp.Regexp {
+	exts := make([]string, 0, len(codecExts)+1)
+	for _, ext := range w.compressExts() {
+		exts = append(exts, regexp.QuoteMeta(ext))
+	}
+	suffix := `(` + strings.Join(exts, "|") + `)?$`
+	if w.Pattern != "" {
+		_, base := splitPath(w.Pattern)
+		return regexp.MustCompile(`^` + patternRegexp(base) + `(\.\d+)?` + suffix)
+	}
+	prefix, ext := prefixAndExt(w.filename(time.Time{}))
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3}` + regexp.QuoteMeta(ext) + suffix)
+}
+
+// codec returns the codec to compress the rotated log files, nil if no compression.
+func (w *Writer) codec() Codec {
+	if w.Compression != nil {
+		return w.Compression
+	}
+	if w.Compress {
+		return GzipCodec{}
+	}
+	return nil
+}
+
+// compressExts returns all the recognized extensions of the compressed files.
+func (w *Writer) compressExts() []string {
+	exts := codecExts
+	if c := w.codec(); c != nil && !slices.Contains(exts, c.Ext()) {
+		exts = append(slices.Clip(exts), c.Ext())
+	}
+	return exts
+}
+
+// trimCompressExt trims the extension of the compressed file, returns false if not compressed.
+func (w *Writer) trimCompressExt(name string) (string, bool) {
+	for _, ext := range w.compressExts() {
+		if trimmed, ok := strings.CutSuffix(name, ext); ok {
+			return trimmed, true
+		}
+	}
+	return name, false
+}
+
+// mill performs post-rotation compression and removal of stale log files in background.
+func (w *Writer) mill() {
+	if w.MaxBackups == 0 && w.MaxAge == 0 && w.MaxTotalSize == 0 && w.codec() == nil {
+		return
+	}
+	current := w.name
+	w.millWg.Add(1)
+	go func() {
+		defer w.millWg.Done()
+		w.millMu.Lock()
+		defer w.millMu.Unlock()
+		_ = w.millRunOnce(current)
+	}()
+}
+
+type logInfo struct {
+	name    string // full path
+	modTime time.Time
+	size    int64
+}
+
+// millRunOnce performs compression and removal of stale log files.
+// Log files are compressed if enabled via configuration and old log
+// files are removed, keeping at most MaxBackups files, as long as
+// none of them are older than MaxAge, and the total size is not over MaxTotalSize.
+func (w *Writer) millRunOnce(current string) error {
+	files, err := w.oldLogFiles(current)
+	if err != nil {
+		return err
+	}
+
+	var compress, remove []logInfo
+	if w.MaxBackups > 0 && w.MaxBackups < len(files) {
+		preserved := make(map[string]bool)
+		var remaining []logInfo
+		for _, f := range files {
+			// Only count the uncompressed log file or the
+			// compressed log file, not both.
+			fn, _ := w.trimCompressExt(f.name)
+			preserved[fn] = true
+
+			if len(preserved) > w.MaxBackups {
+				remove = append(remove, f)
+			} else {
+				remaining = append(remaining, f)
+			}
+		}
+		files = remaining
+	}
+	if w.MaxAge > 0 {
+		cutoff := currentTime().Add(-time.Duration(w.MaxAge) * Daily)
+		var remaining []logInfo
+		for _, f := range files {
+			if f.modTime.Before(cutoff) {
+				remove = append(remove, f)
+			} else {
+				remaining = append(remaining, f)
+			}
+		}
+		files = remaining
+	}
+	codec := w.codec()
+	if codec != nil {
+		for _, f := range files {
+			if _, compressed := w.trimCompressExt(f.name); !compressed {
+				compress = append(compress, f)
+			}
+		}
+	}
+
+	var errs []error
+	for _, f := range remove {
+		if err = os.Remove(f.name); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	for _, f := range compress {
+		if err = compressLogFile(codec, f.name, f.name+codec.Ext()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if w.MaxTotalSize > 0 {
+		if err = w.removeOverQuota(current); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeOverQuota removes the oldest old log files until the total size is not over MaxTotalSize.
+func (w *Writer) removeOverQuota(current string) error {
+	files, err := w.oldLogFiles(current)
+	if err != nil {
+		return err
+	}
+	var total int64
+	if info, err := os.Stat(current); err == nil {
+		total = info.Size()
+	}
+	for _, f := range files {
+		total += f.size
+	}
+	quota := int64(w.MaxTotalSize) * int64(megabyte)
+	var errs []error
+	for i := len(files) - 1; i >= 0 && total > quota; i-- {
+		if err = os.Remove(files[i].name); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			continue
+		}
+		total -= files[i].size
+	}
+	return errors.Join(errs...)
+}
+
+// oldLogFiles returns the list of backup log files stored in the same
+// directory as the current log file, sorted by modification time, newest first.
+func (w *Writer) oldLogFiles(current string) ([]logInfo, error) {
+	dir := w.dir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("rotate: can't read log file directory: %w", err)
+	}
+	re := w.backupRegexp()
+	var files []logInfo
+	for _, e := range entries {
+		if e.IsDir() || !re.MatchString(e.Name()) {
+			continue
+		}
+		name := filepath.Join(dir, e.Name())
+		if name == current {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logInfo{name: name, modTime: info.ModTime(), size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].name > files[j].name
+		}
+		return files[i].modTime.After(files[j].modTime)
+	})
+	return files, nil
+}
+
+// compressLogFile compresses the given log file with the codec, removing the
+// uncompressed log file if successful.
+func compressLogFile(codec Codec, src, dst string) (err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("rotate: failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("rotate: failed to stat log file: %w", err)
+	}
+	dstf, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return fmt.Errorf("rotate: failed to open compressed log file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = dstf.Close()
+			_ = os.Remove(dst)
+		}
+	}()
+
+	cw, err := codec.NewWriter(dstf)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(cw, f); err != nil {
+		return err
+	}
+	if err = cw.Close(); err != nil {
+		return err
+	}
+	if err = dstf.Close(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// prefixAndExt returns the filename part and extension part from the filename,
+// the prefix has a trailing dash, e.g. app-.
+func prefixAndExt(name string) (prefix, ext string) {
+	filename := filepath.Base(name)
+	ext = filepath.Ext(filename)
+	prefix = filename[:len(filename)-len(ext)] + "-"
+	return prefix, ext
+}
+
+// splitPath splits the path into directory and base name.
+func splitPath(path string) (dir, base string) {
+	return filepath.Dir(path), filepath.Base(path)
+}
@@ -0,0 +1,234 @@
+package rotate
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func mockTime(t *testing.T, now *time.Time) {
+	t.Helper()
+	old := currentTime
+	currentTime = func() time.Time { return *now }
+	t.Cleanup(func() { currentTime = old })
+}
+
+func mockMegabyte(t *testing.T, n int) {
+	t.Helper()
+	old := megabyte
+	megabyte = n
+	t.Cleanup(func() { megabyte = old })
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalNames(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got files %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got files %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWriter_PatternDaily(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mockTime(t, &now)
+
+	w := &Writer{
+		Pattern:  filepath.Join(dir, "app-%Y-%m-%d.log"),
+		Interval: Daily,
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("day1\n")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(14*time.Hour + time.Second)
+	if _, err := w.Write([]byte("day2\n")); err != nil {
+		t.Fatal(err)
+	}
+	equalNames(t, listDir(t, dir), []string{"app-2026-10-18.log", "app-2026-10-19.log"})
+
+	b, err := os.ReadFile(filepath.Join(dir, "app-2026-10-19.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "day2\n" {
+		t.Fatalf("unexpected content: %q", b)
+	}
+}
+
+func TestWriter_PatternSizeAndTime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mockTime(t, &now)
+	mockMegabyte(t, 1)
+
+	w := &Writer{
+		Pattern:  filepath.Join(dir, "app-%Y%m%d%H.log"),
+		Interval: Hourly,
+		MaxSize:  10,
+	}
+	defer w.Close()
+	for _, s := range []string{"12345678\n", "12345678\n", "12345678\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	equalNames(t, listDir(t, dir), []string{"app-2026101810.log", "app-2026101810.log.1", "app-2026101810.log.2"})
+}
+
+func TestWriter_IntervalMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mockTime(t, &now)
+
+	w := &Writer{
+		Filename:   filepath.Join(dir, "app.log"),
+		Interval:   Hourly,
+		MaxBackups: 1,
+	}
+	for range 3 {
+		if _, err := w.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Hour)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	equalNames(t, listDir(t, dir), []string{"app-2026-10-18T12-00-00.000.log", "app.log"})
+}
+
+func TestWriter_MaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mockTime(t, &now)
+	mockMegabyte(t, 1)
+
+	w := &Writer{
+		Filename:     filepath.Join(dir, "app.log"),
+		MaxSize:      10,
+		MaxTotalSize: 20,
+	}
+	for range 4 {
+		if _, err := w.Write([]byte("12345678\n")); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Second)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the current file 9 bytes, and only one backup 9 bytes is kept.
+	equalNames(t, listDir(t, dir), []string{"app-2026-10-18T10-00-03.000.log", "app.log"})
+}
+
+func TestParseInterval(t *testing.T) {
+	for s, want := range map[string]time.Duration{"": 0, "hourly": Hourly, "Daily": Daily, "30m": 30 * time.Minute} {
+		got, err := ParseInterval(s)
+		if err != nil || got != want {
+			t.Errorf("ParseInterval(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"weekly", "1ms"} {
+		if _, err := ParseInterval(s); err == nil {
+			t.Errorf("ParseInterval(%q) should failed", s)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	if err := ValidatePattern("logs/app-%Y-%m-%d.log"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"logs/%Y/app.log", "app-%Q.log", "app-%"} {
+		if err := ValidatePattern(s); err == nil {
+			t.Errorf("ValidatePattern(%q) should failed", s)
+		}
+	}
+}
+
+func TestWriter_CompressionZstd(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mockTime(t, &now)
+
+	// the old gzip backup should be recognized by the cleanup.
+	old := filepath.Join(dir, "app-2026-10-17T10-00-00.000.log.gz")
+	if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(old, now.Add(-Daily), now.Add(-Daily)); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Writer{
+		Filename:    filepath.Join(dir, "app.log"),
+		MaxBackups:  1,
+		Compression: ZstdCodec{},
+	}
+	if _, err := w.Write([]byte("hello zstd\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	equalNames(t, listDir(t, dir), []string{"app-2026-10-18T10-00-00.000.log.zst", "app.log"})
+
+	f, err := os.Open(filepath.Join(dir, "app-2026-10-18T10-00-00.000.log.zst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello zstd\n" {
+		t.Fatalf("unexpected content: %q", b)
+	}
+}
+
+func TestParseCodec(t *testing.T) {
+	for s, want := range map[string]Codec{"": nil, "none": nil, "gzip": GzipCodec{}, "gzip:9": GzipCodec{Level: 9}, "zstd:3": ZstdCodec{Level: 3}} {
+		got, err := ParseCodec(s)
+		if err != nil || got != want {
+			t.Errorf("ParseCodec(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"lz4", "gzip:10", "zstd:x", "none:1"} {
+		if _, err := ParseCodec(s); err == nil {
+			t.Errorf("ParseCodec(%q) should failed", s)
+		}
+	}
+}
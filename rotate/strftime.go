@@ -0,0 +1,120 @@
+package rotate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// placeholders supported by the filename pattern.
+//
+//	%Y  year, 4 digits
+//	%y  year, 2 digits
+//	%m  month, 01-12
+//	%d  day of month, 01-31
+//	%H  hour, 00-23
+//	%M  minute, 00-59
+//	%S  second, 00-59
+//	%j  day of year, 001-366
+//	%%  a literal %
+var placeholders = map[byte]string{
+	'Y': `\d{4}`,
+	'y': `\d{2}`,
+	'm': `\d{2}`,
+	'd': `\d{2}`,
+	'H': `\d{2}`,
+	'M': `\d{2}`,
+	'S': `\d{2}`,
+	'j': `\d{3}`,
+	'%': `%`,
+}
+
+// ValidatePattern validates the filename pattern, the placeholders are only allowed in the base name.
+func ValidatePattern(pattern string) error {
+	dir, base := splitPath(pattern)
+	if strings.Contains(dir, "%") {
+		return fmt.Errorf("rotate: placeholder not allowed in directory of pattern %q", pattern)
+	}
+	for i := 0; i < len(base); i++ {
+		if base[i] != '%' {
+			continue
+		}
+		if i+1 >= len(base) {
+			return fmt.Errorf("rotate: pattern %q ends with %%", pattern)
+		}
+		if _, ok := placeholders[base[i+1]]; !ok {
+			return fmt.Errorf("rotate: unsupported placeholder %%%c in pattern %q", base[i+1], pattern)
+		}
+		i++
+	}
+	return nil
+}
+
+// strftime formats the pattern with the time.
+func strftime(pattern string, t time.Time) string {
+	var b strings.Builder
+	b.Grow(len(pattern) + 8)
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 >= len(pattern) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b.WriteString(pad(t.Year(), 4))
+		case 'y':
+			b.WriteString(pad(t.Year()%100, 2))
+		case 'm':
+			b.WriteString(pad(int(t.Month()), 2))
+		case 'd':
+			b.WriteString(pad(t.Day(), 2))
+		case 'H':
+			b.WriteString(pad(t.Hour(), 2))
+		case 'M':
+			b.WriteString(pad(t.Minute(), 2))
+		case 'S':
+			b.WriteString(pad(t.Second(), 2))
+		case 'j':
+			b.WriteString(pad(t.YearDay(), 3))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
+
+// patternRegexp converts the base name of the pattern to a regular expression source.
+func patternRegexp(base string) string {
+	var b strings.Builder
+	literal := 0
+	for i := 0; i < len(base); i++ {
+		if base[i] != '%' || i+1 >= len(base) {
+			continue
+		}
+		expr, ok := placeholders[base[i+1]]
+		if !ok {
+			continue
+		}
+		b.WriteString(regexp.QuoteMeta(base[literal:i]))
+		b.WriteString(expr)
+		i++
+		literal = i + 1
+	}
+	b.WriteString(regexp.QuoteMeta(base[literal:]))
+	return b.String()
+}
+
+func pad(v, width int) string {
+	s := strconv.Itoa(v)
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
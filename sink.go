@@ -0,0 +1,267 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thinkgos/logger/rotate"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// sink type defined
+const (
+	SinkTypeStdout = "stdout" // os.Stdout
+	SinkTypeStderr = "stderr" // os.Stderr
+	SinkTypeFile   = "file"   // plain file
+	SinkTypeRotate = "rotate" // rotating file, see LumberjackFile
+	SinkTypeCustom = "custom" // custom io.Writer, see Config.Writer
+)
+
+// SinkFactory constructs a writer from the params of SinkConfig.
+// The writer is owned by the logger, it is closed on Log.Close or Log.Reload if it is an io.Closer,
+// except os.Stdout and os.Stderr.
+type SinkFactory func(params map[string]any) (zapcore.WriteSyncer, error)
+
+var (
+	sinkMu       sync.RWMutex
+	sinkRegistry = make(map[string]SinkFactory)
+)
+
+// RegisterSink registers a sink factory with the name, so the sink can be
+// referenced by SinkConfig.Sink from the config file.
+// It returns an error if the name is empty or already registered.
+func RegisterSink(name string, factory SinkFactory) error {
+	if name == "" {
+		return errors.New("logger: sink name must not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("logger: sink %q factory must not be nil", name)
+	}
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	if _, ok := sinkRegistry[name]; ok {
+		return fmt.Errorf("logger: sink %q already registered", name)
+	}
+	sinkRegistry[name] = factory
+	return nil
+}
+
+// lookupSink returns the registered sink factory.
+func lookupSink(name string) (SinkFactory, bool) {
+	sinkMu.RLock()
+	defer sinkMu.RUnlock()
+	factory, ok := sinkRegistry[name]
+	return factory, ok
+}
+
+// sinkConfigs returns the sinks of the config.
+// if Config.Sinks is empty, the sinks will be derived from Config.Adapter.
+// the sink name is unique, empty name use the sink type, duplicate name will be suffixed with index.
+func (c *Config) sinkConfigs() []SinkConfig {
+	var sinks []SinkConfig
+	if len(c.Sinks) > 0 {
+		sinks = make([]SinkConfig, len(c.Sinks))
+		copy(sinks, c.Sinks)
+	} else {
+		sinks = c.adapterSinks()
+	}
+	names := make(map[string]struct{}, len(sinks))
+	for i := range sinks {
+		sc := &sinks[i]
+		sc.Type = strings.ToLower(sc.Type)
+		if sc.Type == "" {
+			sc.Type = SinkTypeStdout
+		}
+		if sc.Name == "" {
+			sc.Name = sc.Type
+		}
+		if _, ok := names[sc.Name]; ok {
+			sc.Name = sc.Name + "#" + strconv.Itoa(i)
+		}
+		names[sc.Name] = struct{}{}
+	}
+	return sinks
+}
+
+// adapterSinks maps the Config.Adapter to sinks.
+func (c *Config) adapterSinks() []SinkConfig {
+	fileSink := SinkConfig{
+		Name:   SinkFile,
+		Type:   SinkTypeRotate,
+		Format: c.File.Format,
+		Level:  c.File.Level,
+		File:   c.File,
+	}
+	stdoutSink := SinkConfig{
+		Name:   SinkConsole,
+		Type:   SinkTypeStdout,
+		Format: c.Console.Format,
+		Level:  c.Console.Level,
+	}
+	customSink := SinkConfig{
+		Name:   SinkCustom,
+		Type:   SinkTypeCustom,
+		Format: c.Custom.Format,
+		Level:  c.Custom.Level,
+	}
+	withCustomSink := func(ss ...SinkConfig) []SinkConfig {
+		if len(c.Writer) == 0 {
+			return ss
+		}
+		return append([]SinkConfig{customSink}, ss...)
+	}
+	switch strings.ToLower(c.Adapter) {
+	case AdapterFile:
+		return []SinkConfig{fileSink}
+	case AdapterMulti:
+		return []SinkConfig{stdoutSink, fileSink}
+	case AdapterCustom:
+		return []SinkConfig{customSink}
+	case AdapterFileCustom:
+		return withCustomSink(fileSink)
+	case AdapterConsoleCustom:
+		return withCustomSink(stdoutSink)
+	case AdapterMultiCustom:
+		return withCustomSink(stdoutSink, fileSink)
+	default: // console
+		return []SinkConfig{stdoutSink}
+	}
+}
+
+// toWriter builds the writer of the sink,
+// and the closer of the writer if the writer is owned by the logger, otherwise nil.
+func toWriter(c *Config, sc *SinkConfig) (zapcore.WriteSyncer, io.Closer, error) {
+	switch sc.Type {
+	case SinkTypeStderr:
+		return zapcore.Lock(os.Stderr), nil, nil
+	case SinkTypeFile:
+		f, err := os.OpenFile(fileSinkPath(&sc.File), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zapcore.Lock(f), f, nil
+	case SinkTypeRotate:
+		cf := sc.File
+		if cf.rotateWriter() {
+			w, err := newRotateWriter(&cf)
+			if err != nil {
+				return nil, nil, err
+			}
+			return w, w, nil
+		}
+		w := &lumberjack.Logger{ // 文件切割
+			Filename:   filepath.Join(cf.Path, cf.Filename),
+			MaxSize:    cf.MaxSize,
+			MaxAge:     cf.MaxAge,
+			MaxBackups: cf.MaxBackups,
+			LocalTime:  cf.LocalTime,
+			Compress:   cf.Compress,
+		}
+		return zapcore.AddSync(w), w, nil
+	case SinkTypeCustom:
+		if sc.Sink != "" {
+			factory, ok := lookupSink(sc.Sink)
+			if !ok {
+				return nil, nil, fmt.Errorf("logger: sink %q not registered", sc.Sink)
+			}
+			ws, err := factory(sc.Params)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ws == os.Stdout || ws == os.Stderr { // the std files are shared by the process
+				return ws, nil, nil
+			}
+			closer, _ := ws.(io.Closer) // the writer built by the factory is owned by the logger
+			return ws, closer, nil
+		}
+		ws := make([]zapcore.WriteSyncer, 0, len(c.Writer))
+		for _, writer := range c.Writer {
+			ws = append(ws, zapcore.AddSync(writer))
+		}
+		switch len(ws) {
+		case 0:
+			return zapcore.AddSync(os.Stdout), nil, nil
+		case 1:
+			return ws[0], nil, nil
+		default:
+			return zapcore.NewMultiWriteSyncer(ws...), nil, nil
+		}
+	default: // stdout
+		return zapcore.AddSync(os.Stdout), nil, nil
+	}
+}
+
+// rotateWriter reports whether the file needs the rotate.Writer, which rotates by time and size,
+// otherwise the lumberjack logger is used.
+func (cf *LumberjackFile) rotateWriter() bool {
+	return cf.Rotation != "" || cf.Pattern != "" || cf.Compression != "" || cf.MaxTotalSize > 0
+}
+
+// newRotateWriter builds the writer which rotates by time and size.
+func newRotateWriter(cf *LumberjackFile) (*rotate.Writer, error) {
+	interval, err := rotate.ParseInterval(cf.Rotation)
+	if err != nil {
+		return nil, err
+	}
+	filename := cf.Filename
+	if filename == "" {
+		filename = filepath.Base(os.Args[0]) + "-lumberjack.log"
+	}
+	codec, err := rotate.ParseCodec(cf.Compression)
+	if err != nil {
+		return nil, err
+	}
+	w := &rotate.Writer{
+		Filename:     filepath.Join(cf.Path, filename),
+		Interval:     interval,
+		MaxSize:      cf.MaxSize,
+		MaxAge:       cf.MaxAge,
+		MaxBackups:   cf.MaxBackups,
+		MaxTotalSize: cf.MaxTotalSize,
+		LocalTime:    cf.LocalTime,
+		Compress:     cf.Compress,
+	}
+	if cf.Compression != "" {
+		w.Compress = false
+		w.Compression = codec
+	}
+	if cf.Pattern != "" {
+		w.Pattern = filepath.Join(cf.Path, cf.Pattern)
+		if err = rotate.ValidatePattern(w.Pattern); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// sinkDir returns the directory which the file sink writes to.
+func sinkDir(sc *SinkConfig) string {
+	cf := &sc.File
+	switch {
+	case sc.Type == SinkTypeFile:
+		return filepath.Dir(fileSinkPath(cf))
+	case cf.Pattern != "":
+		return filepath.Dir(filepath.Join(cf.Path, cf.Pattern))
+	case cf.Filename == "" && cf.Path == "" && !cf.rotateWriter():
+		return os.TempDir() // lumberjack writes to the temp directory by default
+	default:
+		return filepath.Dir(filepath.Join(cf.Path, cf.Filename))
+	}
+}
+
+// fileSinkPath returns the path of the plain file sink.
+// empty filename use <processname>.log
+func fileSinkPath(cf *LumberjackFile) string {
+	filename := cf.Filename
+	if filename == "" {
+		filename = filepath.Base(os.Args[0]) + ".log"
+	}
+	return filepath.Join(cf.Path, filename)
+}
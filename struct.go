@@ -0,0 +1,457 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// structTag the struct tag key, format: `log:"name,omitempty,redact"`, `log:"-"` skips the field.
+// name: field name, default the struct field name.
+// omitempty: omit the field if it is zero value.
+// redact: replace the value with RedactConfig.Mask, or handle it with RedactConfig.Action, default ******.
+const structTag = "log"
+
+// maxStructDepth the max nesting depth of the struct, the pointers, the slices and the maps,
+// the deeper value is not encoded, so a cyclic reference does not overflow the stack.
+const maxStructDepth = 64
+
+var errMaxStructDepth = errors.New("logger: exceeded the max depth of the struct, maybe a cyclic reference")
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	objectMarshalerType = reflect.TypeFor[zapcore.ObjectMarshaler]()
+	arrayMarshalerType  = reflect.TypeFor[zapcore.ArrayMarshaler]()
+)
+
+// structPlans caches the plan of the struct type, reflect.Type -> *structPlan
+var structPlans sync.Map
+
+// Struct constructs a field with the struct, see StructMarshaler.
+// It falls back to zap.Any if v is not a struct or a pointer to struct.
+func Struct(key string, v any) Field {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return zap.Any(key, v)
+	}
+	return zap.Object(key, structObject{v: rv})
+}
+
+// StructMarshaler returns a zapcore.ObjectMarshaler of the struct, which honors the `log` struct tag,
+// the encoding plan of the struct type is computed once and cached.
+// The exported fields of the embedded structs are inlined, the value nested deeper than 64 levels,
+// such as a cyclic reference, is not encoded and reported as the marshal error.
+// It returns nil if v is not a struct or a pointer to struct.
+func StructMarshaler(v any) ObjectMarshaler {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	return structObject{v: rv}
+}
+
+// Struct adds the field key with the struct to the *Event context, see logger.Struct.
+func (e *Event) Struct(key string, v any) *Event {
+	if e == nil {
+		return e
+	}
+	e.fields = append(e.fields, Struct(key, v))
+	return e
+}
+
+type structObject struct {
+	v     reflect.Value
+	depth int // the nesting depth of the value, see maxStructDepth
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (o structObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if o.depth > maxStructDepth {
+		return errMaxStructDepth
+	}
+	return planOf(o.v.Type()).encode(enc, o.v, o.depth)
+}
+
+type fieldPlan struct {
+	name      string
+	index     int
+	omitempty bool
+	redact    bool
+	inline    bool // embedded struct without name, its fields are inlined
+	codec     *typeCodec
+}
+
+type structPlan struct {
+	fields []fieldPlan
+}
+
+func planOf(t reflect.Type) *structPlan {
+	if p, ok := structPlans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p, _ := structPlans.LoadOrStore(t, compileStruct(t))
+	return p.(*structPlan)
+}
+
+func compileStruct(t reflect.Type) *structPlan {
+	p := &structPlan{fields: make([]fieldPlan, 0, t.NumField())}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get(structTag)
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fp := fieldPlan{
+			name:      name,
+			index:     i,
+			omitempty: hasTagOption(opts, "omitempty"),
+			redact:    hasTagOption(opts, "redact"),
+		}
+		fp.inline = sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct
+		// the exported fields of the unexported embedded struct are promoted, as encoding/json does.
+		if !sf.IsExported() && !fp.inline {
+			continue
+		}
+		if fp.name == "" {
+			fp.name = sf.Name
+		}
+		fp.codec = codecOf(sf.Type)
+		p.fields = append(p.fields, fp)
+	}
+	return p
+}
+
+func hasTagOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *structPlan) encode(enc zapcore.ObjectEncoder, v reflect.Value, depth int) error {
+	for i := range p.fields {
+		fp := &p.fields[i]
+		fv := v.Field(fp.index)
+		if fp.omitempty && fv.IsZero() {
+			continue
+		}
+		if fp.redact {
+			redactTagged(enc, fp, fv, depth)
+			continue
+		}
+		if fp.inline {
+			if err := planOf(fv.Type()).encode(enc, fv, depth); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fp.codec.add(enc, fp.name, fv, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactTagged redacts the field tagged with redact, it honors RedactConfig.Action and RedactConfig.Mask
+// if the logger redacts, otherwise the value is replaced with ******.
+func redactTagged(enc zapcore.ObjectEncoder, fp *fieldPlan, v reflect.Value, depth int) {
+	re, ok := enc.(*redactObjectEncoder)
+	if !ok {
+		enc.AddString(fp.name, defaultRedactMask)
+		return
+	}
+	switch re.r.action {
+	case RedactDrop:
+	case RedactHash:
+		m := zapcore.NewMapObjectEncoder()
+		_ = fp.codec.add(m, fp.name, v, depth)
+		re.ObjectEncoder.AddString(fp.name, re.r.replace(fmt.Sprint(m.Fields[fp.name])))
+	default:
+		re.ObjectEncoder.AddString(fp.name, re.r.mask)
+	}
+}
+
+// typeCodec encodes the value of the type as an object field or an array element.
+type typeCodec struct {
+	add    func(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error
+	append func(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error
+}
+
+// typeCodecs caches the codec of the type, reflect.Type -> *typeCodec
+var typeCodecs sync.Map
+
+func codecOf(t reflect.Type) *typeCodec {
+	if c, ok := typeCodecs.Load(t); ok {
+		return c.(*typeCodec)
+	}
+	c, _ := typeCodecs.LoadOrStore(t, compileType(t))
+	return c.(*typeCodec)
+}
+
+func compileType(t reflect.Type) *typeCodec {
+	nilable := t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface
+	switch {
+	case t.Implements(objectMarshalerType):
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				if nilable && v.IsNil() {
+					return enc.AddReflected(key, nil)
+				}
+				return enc.AddObject(key, v.Interface().(zapcore.ObjectMarshaler))
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				if nilable && v.IsNil() {
+					return enc.AppendReflected(nil)
+				}
+				return enc.AppendObject(v.Interface().(zapcore.ObjectMarshaler))
+			},
+		}
+	case t.Implements(arrayMarshalerType):
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				if nilable && v.IsNil() {
+					return enc.AddReflected(key, nil)
+				}
+				return enc.AddArray(key, v.Interface().(zapcore.ArrayMarshaler))
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				if nilable && v.IsNil() {
+					return enc.AppendReflected(nil)
+				}
+				return enc.AppendArray(v.Interface().(zapcore.ArrayMarshaler))
+			},
+		}
+	case t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface &&
+		(reflect.PointerTo(t).Implements(objectMarshalerType) || reflect.PointerTo(t).Implements(arrayMarshalerType)):
+		// the marshaler with pointer receiver, encode by the address of the value
+		ptr := codecOf(reflect.PointerTo(t))
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error {
+				return ptr.add(enc, key, addressOf(v), depth)
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error {
+				return ptr.append(enc, addressOf(v), depth)
+			},
+		}
+	case t == timeType:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				enc.AddTime(key, v.Interface().(time.Time))
+				return nil
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				enc.AppendTime(v.Interface().(time.Time))
+				return nil
+			},
+		}
+	case t == durationType:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				enc.AddDuration(key, time.Duration(v.Int()))
+				return nil
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				enc.AppendDuration(time.Duration(v.Int()))
+				return nil
+			},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				enc.AddBool(key, v.Bool())
+				return nil
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				enc.AppendBool(v.Bool())
+				return nil
+			},
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				enc.AddInt64(key, v.Int())
+				return nil
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				enc.AppendInt64(v.Int())
+				return nil
+			},
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				enc.AddUint64(key, v.Uint())
+				return nil
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				enc.AppendUint64(v.Uint())
+				return nil
+			},
+		}
+	case reflect.Float32, reflect.Float64:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				enc.AddFloat64(key, v.Float())
+				return nil
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				enc.AppendFloat64(v.Float())
+				return nil
+			},
+		}
+	case reflect.String:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+				enc.AddString(key, v.String())
+				return nil
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+				enc.AppendString(v.String())
+				return nil
+			},
+		}
+	case reflect.Struct:
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error {
+				return enc.AddObject(key, structObject{v, depth + 1})
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error {
+				return enc.AppendObject(structObject{v, depth + 1})
+			},
+		}
+	case reflect.Pointer:
+		elem := t.Elem()
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error {
+				if v.IsNil() {
+					return enc.AddReflected(key, nil)
+				}
+				if depth >= maxStructDepth {
+					return errMaxStructDepth
+				}
+				return codecOf(elem).add(enc, key, v.Elem(), depth+1)
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error {
+				if v.IsNil() {
+					return enc.AppendReflected(nil)
+				}
+				if depth >= maxStructDepth {
+					return errMaxStructDepth
+				}
+				return codecOf(elem).append(enc, v.Elem(), depth+1)
+			},
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 { // []byte
+			return reflectCodec
+		}
+		elem := t.Elem()
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error {
+				return enc.AddArray(key, sliceArray{v, elem, depth + 1})
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error {
+				return enc.AppendArray(sliceArray{v, elem, depth + 1})
+			},
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return reflectCodec
+		}
+		elem := t.Elem()
+		return &typeCodec{
+			add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error {
+				return enc.AddObject(key, mapObject{v, elem, depth + 1})
+			},
+			append: func(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error {
+				return enc.AppendObject(mapObject{v, elem, depth + 1})
+			},
+		}
+	default:
+		return reflectCodec
+	}
+}
+
+// addressOf returns the address of the value, the value is copied if it is not addressable.
+func addressOf(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p
+}
+
+// reflectCodec encodes the value with the reflection based encoder, such as json.
+var reflectCodec = &typeCodec{
+	add: func(enc zapcore.ObjectEncoder, key string, v reflect.Value, _ int) error {
+		return enc.AddReflected(key, v.Interface())
+	},
+	append: func(enc zapcore.ArrayEncoder, v reflect.Value, _ int) error {
+		return enc.AppendReflected(v.Interface())
+	},
+}
+
+type sliceArray struct {
+	v     reflect.Value
+	elem  reflect.Type
+	depth int
+}
+
+// MarshalLogArray implements zapcore.ArrayMarshaler.
+func (a sliceArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	if a.depth > maxStructDepth {
+		return errMaxStructDepth
+	}
+	codec := codecOf(a.elem)
+	for i := 0; i < a.v.Len(); i++ {
+		if err := codec.append(enc, a.v.Index(i), a.depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type mapObject struct {
+	v     reflect.Value
+	elem  reflect.Type
+	depth int
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler, the keys are sorted.
+func (m mapObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if m.depth > maxStructDepth {
+		return errMaxStructDepth
+	}
+	codec := codecOf(m.elem)
+	keys := m.v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		if err := codec.add(enc, k.String(), m.v.MapIndex(k), m.depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// trace field key default defined
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// TraceContext is the W3C trace context, see https://www.w3.org/TR/trace-context/
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether the trace id and span id are not all zeros.
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+// TraceIDString returns the hex encoded trace id.
+func (tc TraceContext) TraceIDString() string { return hex.EncodeToString(tc.TraceID[:]) }
+
+// SpanIDString returns the hex encoded span id.
+func (tc TraceContext) SpanIDString() string { return hex.EncodeToString(tc.SpanID[:]) }
+
+// FlagsString returns the hex encoded trace flags.
+func (tc TraceContext) FlagsString() string { return hex.EncodeToString([]byte{tc.Flags}) }
+
+// Traceparent returns the W3C traceparent string, format: 00-{trace_id}-{span_id}-{trace_flags}.
+func (tc TraceContext) Traceparent() string {
+	return "00-" + tc.TraceIDString() + "-" + tc.SpanIDString() + "-" + tc.FlagsString()
+}
+
+// ParseTraceparent parses the W3C traceparent string, format: 00-{trace_id}-{span_id}-{trace_flags}.
+func ParseTraceparent(s string) (TraceContext, error) {
+	var tc TraceContext
+
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 {
+		return tc, errors.New("logger: invalid traceparent")
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return tc, errors.New("logger: invalid traceparent version")
+	}
+	if len(traceID) != 32 || !isLowerHex(traceID) {
+		return tc, errors.New("logger: invalid traceparent trace id")
+	}
+	if len(spanID) != 16 || !isLowerHex(spanID) {
+		return tc, errors.New("logger: invalid traceparent span id")
+	}
+	if len(flags) != 2 || !isLowerHex(flags) {
+		return tc, errors.New("logger: invalid traceparent trace flags")
+	}
+	_, _ = hex.Decode(tc.TraceID[:], []byte(traceID))
+	_, _ = hex.Decode(tc.SpanID[:], []byte(spanID))
+	var f [1]byte
+	_, _ = hex.Decode(f[:], []byte(flags))
+	tc.Flags = f[0]
+	if !tc.IsValid() {
+		return tc, errors.New("logger: invalid traceparent, all zeros id")
+	}
+	return tc, nil
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+type traceContextKey struct{}
+
+// ContextWithTrace returns a copy of ctx with the trace context, which extracted by the default extractor of TraceHook.
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// ContextWithTraceparent returns a copy of ctx with the trace context parsed from the W3C traceparent string.
+func ContextWithTraceparent(ctx context.Context, traceparent string) (context.Context, error) {
+	tc, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx, err
+	}
+	return ContextWithTrace(ctx, tc), nil
+}
+
+// TraceFromContext returns the trace context stored by ContextWithTrace.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok && tc.IsValid()
+}
+
+// TraceExtractor extracts the trace context from the context.
+// For OpenTelemetry:
+//
+//	logger.TraceExtractorFunc(func(ctx context.Context) (logger.TraceContext, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return logger.TraceContext{
+//			TraceID: sc.TraceID(),
+//			SpanID:  sc.SpanID(),
+//			Flags:   byte(sc.TraceFlags()),
+//		}, sc.IsValid()
+//	})
+type TraceExtractor interface {
+	ExtractTrace(ctx context.Context) (TraceContext, bool)
+}
+
+// TraceExtractorFunc is an adaptor to allow the use of an ordinary function as a TraceExtractor.
+type TraceExtractorFunc func(ctx context.Context) (TraceContext, bool)
+
+// ExtractTrace implements the TraceExtractor interface.
+func (f TraceExtractorFunc) ExtractTrace(ctx context.Context) (TraceContext, bool) { return f(ctx) }
+
+// TraceHook adds the trace id, span id and trace flags extracted from the Event.Context() as fields.
+type TraceHook struct {
+	extractor TraceExtractor
+	traceKey  string
+	spanKey   string
+	flagsKey  string
+}
+
+// TraceHookOption the option of TraceHook.
+type TraceHookOption func(h *TraceHook)
+
+// WithTraceExtractor set the trace extractor, default TraceFromContext.
+func WithTraceExtractor(extractor TraceExtractor) TraceHookOption {
+	return func(h *TraceHook) {
+		if extractor != nil {
+			h.extractor = extractor
+		}
+	}
+}
+
+// WithTraceKeys set the field keys, default trace_id, span_id, trace_flags.
+// empty key omits the field.
+func WithTraceKeys(traceKey, spanKey, flagsKey string) TraceHookOption {
+	return func(h *TraceHook) {
+		h.traceKey, h.spanKey, h.flagsKey = traceKey, spanKey, flagsKey
+	}
+}
+
+// NewTraceHook new trace hook.
+func NewTraceHook(opts ...TraceHookOption) *TraceHook {
+	h := &TraceHook{
+		extractor: TraceExtractorFunc(TraceFromContext),
+		traceKey:  TraceIDKey,
+		spanKey:   SpanIDKey,
+		flagsKey:  TraceFlagsKey,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RunHook implements the Hook interface.
+func (h *TraceHook) RunHook(e *Event) {
+	tc, ok := h.extractor.ExtractTrace(e.Context())
+	if !ok {
+		return
+	}
+	if h.traceKey != "" {
+		e.String(h.traceKey, tc.TraceIDString())
+	}
+	if h.spanKey != "" {
+		e.String(h.spanKey, tc.SpanIDString())
+	}
+	if h.flagsKey != "" {
+		e.String(h.flagsKey, tc.FlagsString())
+	}
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Watcher watches the config file on disk by stat polling,
+// and applies the changes to the running logger, see Log.Reload.
+type Watcher struct {
+	log       *Log
+	path      string
+	interval  time.Duration
+	onError   func(error)
+	unmarshal func([]byte, any) error
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	content []byte
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// WatcherOption An WatcherOption configures a Watcher.
+type WatcherOption func(*Watcher)
+
+// WithWatchInterval sets the polling interval, default 5s.
+func WithWatchInterval(d time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WithWatchErrorHandler sets the callback which reports the reload errors.
+func WithWatchErrorHandler(f func(error)) WatcherOption {
+	return func(w *Watcher) { w.onError = f }
+}
+
+// WithWatchUnmarshal sets the unmarshal function of the config file.
+// default use yaml for .yaml/.yml, and json for others.
+func WithWatchUnmarshal(f func([]byte, any) error) WatcherOption {
+	return func(w *Watcher) {
+		if f != nil {
+			w.unmarshal = f
+		}
+	}
+}
+
+// NewWatcher new watcher which applies the config file to the logger.
+// The file is unmarshalled into Config, and the non-serializable fields
+// (Writer, EncoderConfig, Clock) are inherited from the current config of the logger.
+func NewWatcher(log *Log, path string, opts ...WatcherOption) *Watcher {
+	w := &Watcher{
+		log:      log,
+		path:     path,
+		interval: 5 * time.Second,
+		onError:  func(error) {},
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		w.unmarshal = yaml.Unmarshal
+	default:
+		w.unmarshal = json.Unmarshal
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Start applies the config file once, and starts polling in background.
+// The polling continues even if the first apply failed.
+func (w *Watcher) Start() error {
+	err := w.Reload()
+	w.mu.Lock()
+	if w.stop == nil {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.run(w.stop, w.done)
+	}
+	w.mu.Unlock()
+	return err
+}
+
+// Stop stops polling, and waits for the background goroutine exit.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func (w *Watcher) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := w.check(); err != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+// check reloads the config file if the stat of file changed.
+func (w *Watcher) check() error {
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	changed := !fi.ModTime().Equal(w.modTime) || fi.Size() != w.size
+	w.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	return w.Reload()
+}
+
+// Reload reads the config file and applies it to the logger immediately,
+// it does nothing if the content not changed.
+// The current configuration is kept if failed.
+func (w *Watcher) Reload() error {
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+	if w.content != nil && bytes.Equal(content, w.content) {
+		return nil
+	}
+	var c Config
+	if err = w.unmarshal(content, &c); err != nil {
+		return err
+	}
+	if w.log.pipe != nil {
+		current := w.log.pipe.config()
+		c.Writer = current.Writer
+		c.EncoderConfig = current.EncoderConfig
+		c.Clock = current.Clock
+	}
+	if err = w.log.Reload(c); err != nil {
+		return err
+	}
+	w.content = content
+	return nil
+}
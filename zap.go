@@ -1,13 +1,17 @@
 package logger
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type AtomicLevel = zap.AtomicLevel
@@ -54,12 +58,63 @@ const (
 	EncodeLevelCapitalColor   = "CapitalColorLevelEncoder"   // 大写编码器带颜色
 )
 
+// time encoding defined
+const (
+	TimeEncodingRFC3339     = "rfc3339"      // 2006-01-02T15:04:05Z07:00
+	TimeEncodingRFC3339Nano = "rfc3339nano"  // 2006-01-02T15:04:05.999999999Z07:00
+	TimeEncodingISO8601     = "iso8601"      // 2006-01-02T15:04:05.000Z0700
+	TimeEncodingEpoch       = "epoch"        // floating-point seconds since the Unix epoch
+	TimeEncodingEpochMillis = "epoch-millis" // floating-point milliseconds since the Unix epoch
+	TimeEncodingEpochNanos  = "epoch-nanos"  // integer nanoseconds since the Unix epoch
+)
+
+// caller encoding defined
+const (
+	CallerEncodingAuto    = "auto"    // full if the level is debug, otherwise short
+	CallerEncodingShort   = "short"   // package/file:line
+	CallerEncodingFull    = "full"    // /full/path/to/package/file:line
+	CallerEncodingPackage = "package" // import/path/of/package/file:line
+)
+
+// duration encoding defined
+const (
+	DurationEncodingString  = "string"  // 1.5s
+	DurationEncodingSeconds = "seconds" // floating-point seconds
+	DurationEncodingMillis  = "millis"  // floating-point milliseconds
+	DurationEncodingNanos   = "nanos"   // integer nanoseconds
+)
+
+// name encoding defined
+const (
+	NameEncodingFull  = "full"  // db.query
+	NameEncodingShort = "short" // query
+)
+
+// sink name defined
+const (
+	SinkConsole = "console" // console sink
+	SinkFile    = "file"    // file sink
+	SinkCustom  = "custom"  // custom io.Writer sink
+)
+
 // New constructs a new Log
 func New(opts ...Option) (*zap.Logger, AtomicLevel) {
+	l, lv, _, _ := newWithConfig(newConfig(opts...))
+	return l, lv
+}
+
+func newConfig(opts ...Option) *Config {
 	c := &Config{}
 	for _, opt := range opts {
 		opt(c)
 	}
+	return c
+}
+
+// newWithConfig constructs a new zap logger with the config.
+// it always returns a usable logger, the sink which failed to build falls back to os.Stderr,
+// and the errors are returned.
+func newWithConfig(c *Config) (*zap.Logger, AtomicLevel, *pipeline, error) {
 	var options []zap.Option
 
 	if c.Stack {
@@ -67,46 +122,140 @@ func New(opts ...Option) (*zap.Logger, AtomicLevel) {
 		options = append(options, zap.AddStacktrace(zap.NewAtomicLevelAt(zap.DPanicLevel))) // 只显示栈的错误等级
 	}
 
+	if c.Clock != nil {
+		options = append(options, zap.WithClock(c.Clock))
+	}
+
 	level, err := zap.ParseAtomicLevel(c.Level)
 	if err != nil {
 		level = zap.NewAtomicLevelAt(zap.WarnLevel)
 	}
 
-	// 初始化core
-	core := zapcore.NewCore(
-		toEncoder(c, level), // 设置encoder
-		toWriter(c),         // 设置输出
-		level,               // 设置日志输出等级
-	)
-	return zap.New(core, options...), level
+	names := newNameLevels()
+	nameLevels, nameErr := parseNameLevels(c.NameLevels)
+	if nameErr == nil {
+		names.replace(nameLevels)
+	}
+	state, err := newCore(c, level, level.Level(), names)
+	pipe := newPipeline(c, state, names)
+	return zap.New(pipe.core, options...), level, pipe, errors.Join(nameErr, err)
 }
 
-func toEncoder(c *Config, level AtomicLevel) zapcore.Encoder {
+// newCore builds a tee core, each sink has its own core with its own level and encoder,
+// and the tee core is gated by the logger level or the override level of the logger name.
+// the sink which failed to build falls back to os.Stderr, and the errors are returned.
+// lvl is the initial logger level, which decide the default caller encoder.
+func newCore(c *Config, level AtomicLevel, lvl Level, names *nameLevels) (*coreState, error) {
+	sinks := c.sinkConfigs()
+	cores := make([]zapcore.Core, 0, len(sinks))
+	state := newCoreState(nil)
+	state.levels = make(map[string]AtomicLevel, len(sinks))
+	var errs []error
+	redact, err := newRedactor(&c.Redact) // shared by the sinks
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if redact == nil {
+		redact = newTagRedactor(&c.Redact)
+	}
+	var guards []*diskGuard
+	var guardAt []int // index of the guarded core
+	for i := range sinks {
+		sc := &sinks[i]
+		sinkLevel := zap.NewAtomicLevelAt(zap.DebugLevel) // 默认不额外过滤
+		if sc.Level != "" {
+			if lv, err := zap.ParseAtomicLevel(sc.Level); err == nil {
+				sinkLevel = lv
+			}
+		}
+		writer, closer, err := toWriter(c, sc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("logger: build sink %q: %w", sc.Name, err))
+			writer = zapcore.Lock(os.Stderr)
+		}
+		if closer != nil {
+			state.closers = append(state.closers, closer)
+		}
+		state.levels[sc.Name] = sinkLevel
+		var core zapcore.Core
+		if c.Async.Enable {
+			// 异步写入, 同步编码
+			aw := newAsyncWriter(writer, &c.Async)
+			if state.asyncs == nil {
+				state.asyncs = make(map[string]*asyncWriter, len(sinks))
+			}
+			state.asyncs[sc.Name] = aw
+			core = newAsyncCore(toEncoder(c, sc, lvl, redact), aw, sinkLevel)
+		} else {
+			core = zapcore.NewCore(
+				toEncoder(c, sc, lvl, redact), // 设置encoder
+				writer,                        // 设置输出
+				sinkLevel,                     // 设置日志输出等级
+			)
+		}
+		if (sc.Type == SinkTypeFile || sc.Type == SinkTypeRotate) && sc.File.MinFreeSpace > 0 {
+			guard := newDiskGuard(sinkDir(sc), sc.File.MinFreeSpace, sc.File.LowSpacePolicy)
+			guards = append(guards, guard)
+			guardAt = append(guardAt, len(cores))
+			core = &diskGuardCore{Core: core, guard: guard} // 磁盘可用空间检查
+		}
+		cores = append(cores, core)
+	}
+	// the low space warning is written to the other sinks, not the full one.
+	for i, guard := range guards {
+		others := make([]zapcore.Core, 0, len(cores)-1)
+		others = append(others, cores[:guardAt[i]]...)
+		others = append(others, cores[guardAt[i]+1:]...)
+		if len(others) > 0 {
+			guard.others = zapcore.NewTee(others...)
+		}
+	}
+	core := zapcore.NewTee(cores...)
+	if c.Sampling.Initial > 0 || c.Sampling.Thereafter > 0 {
+		tick := time.Second
+		if d, err := time.ParseDuration(c.Sampling.Tick); err == nil && d > 0 {
+			tick = d
+		}
+		core = zapcore.NewSamplerWithOptions(core, tick, c.Sampling.Initial, c.Sampling.Thereafter)
+	}
+	if c.Dedup.Enable {
+		core = newDedupCore(core, &c.Dedup)
+	}
+	state.core = &levelCore{Core: core, level: level, names: names}
+	return state, errors.Join(errs...)
+}
+
+func toEncoder(c *Config, sc *SinkConfig, lvl Level, redact *redactor) zapcore.Encoder {
 	encoderConfig := c.EncoderConfig
 	if encoderConfig == nil {
+		es := &c.Encoder
 		encoderConfig = &zapcore.EncoderConfig{
-			TimeKey:        "ts",
-			LevelKey:       "level",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			FunctionKey:    zapcore.OmitKey,
-			MessageKey:     "msg",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    toEncodeLevel(c.EncodeLevel),
-			EncodeTime:     zapcore.RFC3339TimeEncoder,
-			EncodeDuration: zapcore.StringDurationEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-		}
-		if level.Level() == DebugLevel {
-			encoderConfig.EncodeCaller = zapcore.FullCallerEncoder
+			TimeKey:        toEncodeKey(c.TimeKey, "ts"),
+			LevelKey:       toEncodeKey(es.LevelKey, "level"),
+			NameKey:        toEncodeKey(es.NameKey, "logger"),
+			CallerKey:      toEncodeKey(es.CallerKey, "caller"),
+			FunctionKey:    toEncodeKey(es.FunctionKey, zapcore.OmitKey),
+			MessageKey:     toEncodeKey(es.MessageKey, "msg"),
+			StacktraceKey:  toEncodeKey(es.StacktraceKey, "stacktrace"),
+			LineEnding:     cmp.Or(es.LineEnding, zapcore.DefaultLineEnding),
+			EncodeLevel:    toEncodeLevel(cmp.Or(sc.EncodeLevel, c.EncodeLevel)),
+			EncodeTime:     toEncodeTime(c.TimeEncoding, c.TimeZone),
+			EncodeDuration: toEncodeDuration(es.DurationEncoding),
+			EncodeCaller:   toEncodeCaller(es.CallerEncoding, lvl),
+			EncodeName:     toEncodeName(es.NameEncoding),
 		}
 	}
 
-	if c.Format == FormatConsole {
-		return zapcore.NewConsoleEncoder(*encoderConfig)
+	var enc zapcore.Encoder
+	if cmp.Or(sc.Format, c.Format) == FormatConsole {
+		enc = zapcore.NewConsoleEncoder(*encoderConfig)
+	} else {
+		enc = zapcore.NewJSONEncoder(*encoderConfig)
 	}
-	return zapcore.NewJSONEncoder(*encoderConfig)
+	if redact != nil {
+		enc = newRedactEncoder(enc, redact) // 敏感字段脱敏
+	}
+	return enc
 }
 
 func toEncodeLevel(l string) zapcore.LevelEncoder {
@@ -124,53 +273,119 @@ func toEncodeLevel(l string) zapcore.LevelEncoder {
 	}
 }
 
-func toWriter(c *Config) zapcore.WriteSyncer {
-	cf := c.File
-	fileWriter := func() zapcore.WriteSyncer {
-		return zapcore.AddSync(&lumberjack.Logger{ // 文件切割
-			Filename:   filepath.Join(cf.Path, cf.Filename),
-			MaxSize:    cf.MaxSize,
-			MaxAge:     cf.MaxAge,
-			MaxBackups: cf.MaxBackups,
-			LocalTime:  cf.LocalTime,
-			Compress:   cf.Compress,
-		})
-	}
-	stdoutWriter := func() zapcore.WriteSyncer {
-		return zapcore.AddSync(os.Stdout)
-	}
-	customWriter := func(w ...zapcore.WriteSyncer) []zapcore.WriteSyncer {
-		ws := make([]zapcore.WriteSyncer, 0, len(c.Writer)+len(w))
-
-		for _, writer := range c.Writer {
-			ws = append(ws, zapcore.AddSync(writer))
-		}
-		for _, writer := range w {
-			ws = append(ws, zapcore.AddSync(writer))
-		}
-		return ws
-	}
-	switch strings.ToLower(c.Adapter) {
-	case AdapterFile:
-		return fileWriter()
-	case AdapterMulti:
-		return zapcore.NewMultiWriteSyncer(stdoutWriter(), fileWriter())
-	case AdapterCustom:
-		ws := customWriter()
-		if len(ws) == 0 {
-			return stdoutWriter()
+// toEncodeKey returns the default key if the key is empty, "-" means omit the key.
+func toEncodeKey(key, def string) string {
+	switch key {
+	case "":
+		return def
+	case "-":
+		return zapcore.OmitKey
+	default:
+		return key
+	}
+}
+
+func toEncodeTime(encoding, zone string) zapcore.TimeEncoder {
+	var enc zapcore.TimeEncoder
+	switch encoding {
+	case "", TimeEncodingRFC3339:
+		enc = zapcore.RFC3339TimeEncoder
+	case TimeEncodingRFC3339Nano:
+		enc = zapcore.RFC3339NanoTimeEncoder
+	case TimeEncodingISO8601:
+		enc = zapcore.ISO8601TimeEncoder
+	case TimeEncodingEpoch:
+		enc = zapcore.EpochTimeEncoder
+	case TimeEncodingEpochMillis:
+		enc = zapcore.EpochMillisTimeEncoder
+	case TimeEncodingEpochNanos:
+		enc = zapcore.EpochNanosTimeEncoder
+	default: // custom layout
+		enc = zapcore.TimeEncoderOfLayout(encoding)
+	}
+	loc, err := toLocation(zone)
+	if err != nil || loc == nil {
+		return enc
+	}
+	return func(t time.Time, pae zapcore.PrimitiveArrayEncoder) {
+		enc(t.In(loc), pae)
+	}
+}
+
+func toEncodeDuration(encoding string) zapcore.DurationEncoder {
+	switch encoding {
+	case DurationEncodingSeconds:
+		return zapcore.SecondsDurationEncoder
+	case DurationEncodingMillis:
+		return zapcore.MillisDurationEncoder
+	case DurationEncodingNanos:
+		return zapcore.NanosDurationEncoder
+	case DurationEncodingString:
+		fallthrough
+	default:
+		return zapcore.StringDurationEncoder
+	}
+}
+
+// toEncodeCaller the auto encoding uses the full caller if the logger level is debug.
+func toEncodeCaller(encoding string, lvl Level) zapcore.CallerEncoder {
+	switch encoding {
+	case CallerEncodingShort:
+		return zapcore.ShortCallerEncoder
+	case CallerEncodingFull:
+		return zapcore.FullCallerEncoder
+	case CallerEncodingPackage:
+		return packageCallerEncoder
+	case CallerEncodingAuto:
+		fallthrough
+	default:
+		if lvl == DebugLevel {
+			return zapcore.FullCallerEncoder
 		}
-		if len(ws) == 1 {
-			return ws[0]
+		return zapcore.ShortCallerEncoder
+	}
+}
+
+// packageCallerEncoder serializes a caller in package/file:line format,
+// the package is the import path of the caller function.
+func packageCallerEncoder(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	pkg := callerPackage(caller.Function)
+	if !caller.Defined || pkg == "" {
+		zapcore.ShortCallerEncoder(caller, enc)
+		return
+	}
+	enc.AppendString(pkg + "/" + filepath.Base(caller.File) + ":" + strconv.Itoa(caller.Line))
+}
+
+// callerPackage returns the import path of the function,
+// e.g. github.com/thinkgos/logger.(*Log).Info => github.com/thinkgos/logger
+func callerPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return ""
+}
+
+func toEncodeName(encoding string) zapcore.NameEncoder {
+	if encoding == NameEncodingShort {
+		return func(name string, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(name[strings.LastIndexByte(name, '.')+1:])
 		}
-		return zapcore.NewMultiWriteSyncer(ws...)
-	case AdapterFileCustom:
-		return zapcore.NewMultiWriteSyncer(customWriter(fileWriter())...)
-	case AdapterConsoleCustom:
-		return zapcore.NewMultiWriteSyncer(customWriter(stdoutWriter())...)
-	case AdapterMultiCustom:
-		return zapcore.NewMultiWriteSyncer(customWriter(stdoutWriter(), fileWriter())...)
-	default: // console
-		return stdoutWriter()
+	}
+	return zapcore.FullNameEncoder
+}
+
+// toLocation returns nil if the zone is empty, which keeps the location of the time.
+func toLocation(zone string) (*time.Location, error) {
+	switch zone {
+	case "":
+		return nil, nil
+	case "UTC", "utc":
+		return time.UTC, nil
+	case "Local", "local":
+		return time.Local, nil
+	default:
+		return time.LoadLocation(zone)
 	}
 }